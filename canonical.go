@@ -0,0 +1,107 @@
+package filever
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/cyphrme/coze"
+)
+
+// DummyVersion returns the dummy (zeroed) version, e.g. `00000000`.
+func DummyVersion() string {
+	return strings.Repeat("0", VersionSize)
+}
+
+// Canonical returns a copy of b with every delimited version (`~fv=<anything>`)
+// replaced with the delimited dummy version, e.g. `app~fv=4mIbJJPq.min.js`
+// becomes `app~fv=00000000.min.js`.
+//
+// Canonical "re-zeroes" a file so that a file in `src` using dummy references
+// and the same file in `dist` after Replace() produce the same digest.
+func Canonical(b []byte) []byte {
+	return VerAnySizeRegexC.ReplaceAllLiteral(b, []byte(Delim+DummyVersion()))
+}
+
+// CanonicalDigest returns the digest of the canonicalized bytes.  See
+// Canonical().
+func CanonicalDigest(b []byte, alg coze.HshAlg) (digest coze.B64, err error) {
+	d, err := coze.Hash(alg, Canonical(b))
+	if err != nil {
+		return nil, err
+	}
+	return coze.B64(d), nil
+}
+
+// HashFileCanonical is like HashFile, but returns the canonical digest.  The
+// returned file is the file as read, not canonicalized.
+func HashFileCanonical(path string, alg coze.HshAlg) (digest coze.B64, file *[]byte, err error) {
+	fileBytes, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	digest, err = CanonicalDigest(fileBytes, alg)
+	if err != nil {
+		return nil, nil, err
+	}
+	return digest, &fileBytes, nil
+}
+
+// Verify checks that the version in the file name of the versioned file at
+// `path` matches the file's canonical digest.  Dummy versioned files are not
+// verifiable and return an error.
+func Verify(path string) (err error) {
+	p := Populated(filepath.ToSlash(path))
+	if p.FileVer == "" {
+		return fmt.Errorf("%s is not a versioned file", path)
+	}
+	if p.Version == DummyVersion() {
+		return fmt.Errorf("%s has a dummy version", path)
+	}
+
+	dig, _, err := HashFileCanonical(path, HashAlg)
+	if err != nil {
+		return err
+	}
+	if !versionMatches(p.Version, dig.String()) {
+		return fmt.Errorf("%s version %s does not match canonical digest %s", path, p.Version, dig)
+	}
+	return nil
+}
+
+// VerifyDir verifies all non-dummy versioned files, recursively, in directory.
+// Mismatched are the paths, relative to pwd, of versioned files whose names do
+// not match their canonical digest.  Returned `err` is only for errors
+// encountered walking or reading files.
+func VerifyDir(directory string) (mismatched []string, err error) {
+	var walk = func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		p := Populated(d.Name())
+		if p.FileVer == "" || p.Version == DummyVersion() {
+			return nil
+		}
+
+		dig, _, err := HashFileCanonical(path, HashAlg)
+		if err != nil {
+			return err
+		}
+		if !versionMatches(p.Version, dig.String()) {
+			mismatched = append(mismatched, path)
+		}
+		return nil
+	}
+
+	return mismatched, filepath.WalkDir(directory, walk)
+}
+
+// versionMatches reports whether version is a valid truncation of digest.
+func versionMatches(version, digest string) bool {
+	return len(version) >= VersionSize && strings.HasPrefix(digest, version)
+}
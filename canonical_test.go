@@ -0,0 +1,43 @@
+package filever
+
+import "fmt"
+
+func ExampleCanonical() {
+	b := []byte(`import * as test1 from '../test_1~fv=vPCb4GVO.js';
+import * as test2 from '../test_2~fv=00000000.js';
+import * as test4 from '../subdir/test_4~fv=GJIrg6k1Extended.js';`)
+
+	fmt.Printf("%s\n", Canonical(b))
+
+	// Output:
+	// import * as test1 from '../test_1~fv=00000000.js';
+	// import * as test2 from '../test_2~fv=00000000.js';
+	// import * as test4 from '../subdir/test_4~fv=00000000.js';
+}
+
+// ExampleVerifyDir demonstrates that versioned files in `dist`, after Replace()
+// has updated their references, are verifiable from `dist` alone.
+func ExampleVerifyDir() {
+	c := &Config{Src: dummySrc, Dist: dummyDist}
+	err := VersionReplace(c)
+	if err != nil {
+		panic(err)
+	}
+
+	mismatched, err := VerifyDir(dummyDist)
+	if err != nil {
+		panic(err)
+	}
+	fmt.Println(mismatched)
+
+	err = Verify(dummyDist + "/" + c.Info.VersionedFiles[0])
+	fmt.Println(err)
+
+	err = Verify(dummySrc + "/test_1~fv=00000000.js")
+	fmt.Println(err)
+
+	// Output:
+	// []
+	// <nil>
+	// test/dummy/src/test_1~fv=00000000.js has a dummy version
+}
@@ -0,0 +1,211 @@
+package filever
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// CheckKind is the kind of a CheckDiff.
+type CheckKind string
+
+const (
+	// CheckMissing is a current versioned file missing from c.Dist.
+	CheckMissing CheckKind = "missing"
+	// CheckStale is a current versioned file in c.Dist whose content differs
+	// from the versioned and replaced src file.
+	CheckStale CheckKind = "stale"
+	// CheckOld is a previous version still present in c.Dist.
+	CheckOld CheckKind = "old"
+	// CheckReference is a reference in c.Dist to a non-current version.
+	CheckReference CheckKind = "reference"
+)
+
+// CheckDiff is a difference, found by Check(), between c.Dist and what
+// VersionReplace() would produce.
+//
+//	Kind   - Kind of the difference.
+//	Path   - File in c.Dist, relative to pwd.
+//	Line   - For CheckReference, line of the reference, starting at 1.
+//	Column - For CheckReference, column, in bytes, of the reference, starting at 1.
+//	Ref    - For CheckReference, the reference in c.Dist.
+//	Want   - For CheckReference, the current reference.
+type CheckDiff struct {
+	Kind   CheckKind
+	Path   string
+	Line   int    `json:",omitempty"`
+	Column int    `json:",omitempty"`
+	Ref    string `json:",omitempty"`
+	Want   string `json:",omitempty"`
+}
+
+// String returns the difference as a line of a diff-style listing, e.g.
+// `+ dist/app~fv=4mIbJJPq.js (missing)`, `! dist/app~fv=4mIbJJPq.js (stale)`,
+// `- dist/app~fv=Ab7ZeAgj.js (old)`, or
+// `dist/index.html:3:14: -app~fv=Ab7ZeAgj.js +app~fv=4mIbJJPq.js`.
+func (d CheckDiff) String() string {
+	switch d.Kind {
+	case CheckMissing:
+		return "+ " + d.Path + " (missing)"
+	case CheckStale:
+		return "! " + d.Path + " (stale)"
+	case CheckOld:
+		return "- " + d.Path + " (old)"
+	}
+	return fmt.Sprintf("%s:%d:%d: -%s +%s", d.Path, d.Line, d.Column, d.Ref, d.Want)
+}
+
+// Check reports, without writing, whether c.Dist is what VersionReplace()
+// would produce from c.Src, e.g. to assert in CI that a committed dist is
+// current.  Returns a *CheckError listing every current versioned file that is
+// missing or stale, every previous version still present (unless c.Retention
+// is set), and every reference to a non-current version.  c.Transformers are
+// not run, and compressed siblings, the manifest and history are not checked.
+// With c.Collision CollisionExtend, versions colliding with files in c.Dist are
+// extended like Version().
+//
+// Populates c.Info.PV and c.Info.VersionedFiles.
+func Check(c *Config) error {
+	return CheckContext(context.Background(), c)
+}
+
+// CheckContext is Check() stopping, with ctx.Err(), when ctx is done.
+func CheckContext(ctx context.Context, c *Config) (err error) {
+	c.Info = new(Info)
+	c.Info.PV = map[string]string{}
+	c.Info.VersionedFiles = []string{}
+	c.Info.versions = map[string]versionOwner{}
+	if c.SrcFiles == nil {
+		c.SrcFiles, err = srcVersionedFiles(ctx, c)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Version in memory.
+	contents := map[string][]byte{} // Src content by versioned file, relative to c.Dist.
+	var errs []error
+	for _, f := range c.SrcFiles {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		in := filepath.Join(c.Src, f)
+		dig, b, err := HashFileCanonical(in, HashAlg)
+		if err != nil {
+			errs = append(errs, fileOpErr("hash", in, err))
+			continue
+		}
+		bare := Populated(filepath.ToSlash(f)).BarePath
+		// With CollisionExtend, files in c.Dist extend versions like Version().
+		// Otherwise, a file in c.Dist of the same version is reported as stale.
+		var dir string
+		var versions []string
+		if c.Collision == CollisionExtend {
+			dir = filepath.Join(c.Dist, filepath.Dir(f))
+			versions, err = distVersions(c, f, dir)
+			if err != nil {
+				return err
+			}
+		}
+		v, err := version(c, bare, dig.String(), dir, versions)
+		if err != nil {
+			return err
+		}
+		c.Info.versions[v] = versionOwner{bare: bare, digest: dig.String()}
+		fileVer, _ := genFileVer(f, v, c)
+		c.Info.PV[bare] = v
+		c.Info.VersionedFiles = append(c.Info.VersionedFiles, fileVer)
+		contents[fileVer] = *b
+	}
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+	err = genSrcReg(c)
+	if err != nil {
+		return err
+	}
+
+	var diffs []CheckDiff
+	for _, fileVer := range c.Info.VersionedFiles {
+		name := filepath.Join(c.Dist, fileVer)
+		want := contents[fileVer]
+		if isText(c, name, want) {
+			want, _, _ = replaceRefs(c, name, want)
+		}
+		got, err := os.ReadFile(name)
+		switch {
+		case os.IsNotExist(err):
+			diffs = append(diffs, CheckDiff{Kind: CheckMissing, Path: name})
+		case err != nil:
+			errs = append(errs, fileOpErr("read", name, err))
+		case !bytes.Equal(got, want):
+			diffs = append(diffs, CheckDiff{Kind: CheckStale, Path: name})
+		}
+
+		if c.Retention != nil { // Previous versions are removed by Prune().
+			continue
+		}
+		versions, err := distVersions(c, fileVer, filepath.Dir(name))
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		for _, v := range versions {
+			if v != filepath.Base(fileVer) {
+				diffs = append(diffs, CheckDiff{Kind: CheckOld, Path: filepath.Join(filepath.Dir(name), v)})
+			}
+		}
+	}
+
+	f, err := newFilter(c, c.Dist)
+	if err != nil {
+		return err
+	}
+	err = walkFiles(ctx, c.Dist, f, func(path, _ string, _ fs.DirEntry) error {
+		if isPreviousVersion(c, path) || isCompressedSibling(path) {
+			return nil
+		}
+		b, err := os.ReadFile(path)
+		if err != nil {
+			errs = append(errs, fileOpErr("read", path, err))
+			return nil
+		}
+		if !isText(c, path, b) {
+			return nil
+		}
+		for _, m := range c.refs.FindAllIndex(b, -1) {
+			ref := string(b[m[0]:m[1]])
+			want, _, _ := currentRef(c, path, ref)
+			if want != ref {
+				line, col := lineCol(b, m[0])
+				diffs = append(diffs, CheckDiff{Kind: CheckReference, Path: path, Line: line, Column: col, Ref: ref, Want: want})
+			}
+		}
+		return nil
+	})
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	if err != nil || len(errs) > 0 {
+		return errors.Join(append(errs, err)...)
+	}
+
+	if len(diffs) == 0 {
+		return nil
+	}
+	sort.SliceStable(diffs, func(i, j int) bool {
+		if diffs[i].Path != diffs[j].Path {
+			return diffs[i].Path < diffs[j].Path
+		}
+		if diffs[i].Line != diffs[j].Line {
+			return diffs[i].Line < diffs[j].Line
+		}
+		return diffs[i].Column < diffs[j].Column
+	})
+	return &CheckError{Diffs: diffs}
+}
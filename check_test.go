@@ -0,0 +1,94 @@
+package filever
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+)
+
+// ExampleCheck demonstrates checking that dist is current, e.g. in CI.
+func ExampleCheck() {
+	err := os.RemoveAll(checkDist)
+	if err != nil {
+		panic(err)
+	}
+	err = os.MkdirAll(checkDist, 0755)
+	if err != nil {
+		panic(err)
+	}
+	err = VersionReplace(&Config{Src: dummySrc, Dist: checkDist})
+	if err != nil {
+		panic(err)
+	}
+	fmt.Println(Check(&Config{Src: dummySrc, Dist: checkDist}))
+
+	// Make dist out of date.
+	err = os.Remove(checkDist + "/subdir/test_3~fv=_X83uO__.js")
+	if err != nil {
+		panic(err)
+	}
+	f, err := os.OpenFile(checkDist+"/test_2~fv=BOl7h9TM.js", os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		panic(err)
+	}
+	_, err = f.WriteString("// Edited in dist.\n")
+	if err != nil {
+		panic(err)
+	}
+	f.Close()
+	for name, content := range map[string]string{
+		checkDist + "/test_1~fv=Ab7ZeAgj.js": "// Old version.\n",
+		checkDist + "/index.html":            "<html>\n<script src=\"test_1~fv=Ab7ZeAgj.js\"></script>\n",
+	} {
+		err = os.WriteFile(name, []byte(content), 0644)
+		if err != nil {
+			panic(err)
+		}
+	}
+
+	err = Check(&Config{Src: dummySrc, Dist: checkDist})
+	var ce *CheckError
+	fmt.Println(errors.As(err, &ce))
+	fmt.Println(err)
+
+	// Output:
+	// <nil>
+	// true
+	// 4 differences from a fresh run:
+	// test/check/index.html:2:14: -test_1~fv=Ab7ZeAgj.js +test_1~fv=vPCb4GVO.js
+	// + test/check/subdir/test_3~fv=_X83uO__.js (missing)
+	// - test/check/test_1~fv=Ab7ZeAgj.js (old)
+	// ! test/check/test_2~fv=BOl7h9TM.js (stale)
+}
+
+// TestCheck_collisionExtend tests that a dist produced with CollisionExtend,
+// with a version extended because of a colliding file in dist, is current.
+func TestCheck_collisionExtend(t *testing.T) {
+	err := os.RemoveAll(checkCollisionDist)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.MkdirAll(checkCollisionDist, 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Different content with the version test_1.js will get.
+	err = os.WriteFile(checkCollisionDist+"/test_1~fv=vPCb4GVO.js", []byte("// Colliding.\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := &Config{Src: dummySrc, Dist: checkCollisionDist, Collision: CollisionExtend, Retention: &Retention{}}
+	err = VersionReplace(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v := c.Info.PV["test_1.js"]; v != "vPCb4GVOi" {
+		t.Fatalf("version of test_1.js = %s, want vPCb4GVOi", v)
+	}
+
+	err = Check(&Config{Src: dummySrc, Dist: checkCollisionDist, Collision: CollisionExtend, Retention: &Retention{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
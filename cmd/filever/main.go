@@ -0,0 +1,272 @@
+// Command filever is the command line interface for package filever.
+//
+// Usage:
+//
+//	filever [version] -src <src> -dist <dist> [-savr] [-dangling warn|error|ignore] [-report <report.json>] [-check] [-lock off|frozen|update] [-lock-file filever.lock]
+//	filever watch -src <src> -dist <dist> [-savr] [-debounce 100ms] [-watch-dist]
+//	filever unversion -dist <dist> -out <out>
+//	filever generate -src <src> -dist <dist> -pkg <package> -o <file.go>
+//	filever migrate -dir <dir> [-from-delim ~fv=] [-from-size 8] [-from-format mid] [-to-delim ~fv=] [-to-size 8] [-to-format mid] [-dry-run]
+//
+// Command `version` (the default) runs Version() and Replace(), and optionally
+// writes the JSON report of the run, e.g. for CI.  With -check, nothing is
+// written and `version` fails, listing the differences, if dist is not what a
+// fresh run would produce.  Command `watch`
+// runs `version` and then re-versions and replaces on changes until
+// interrupted.  Command `unversion` rebuilds a `src` tree from an existing `dist`.  Command `generate`
+// runs `version` and writes a Go source file with a typed asset table, e.g.
+// for `go generate`:
+//
+//	//go:generate filever generate -src src -dist dist -pkg assets -o assets_filever.go
+//
+// Command `migrate` rewrites file names and references from one naming scheme
+// to another.  See package filever.
+//
+// Flag -savr sets Config.UseSAVR, which uses a literal matcher instead of the
+// former "Search All Versioned, Regex".  The name is kept for compatibility.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+
+	"github.com/cyphrme/filever"
+)
+
+func main() {
+	err := run(os.Args[1:])
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+// run runs the subcommand given by args[0].  If args[0] is a flag or args is
+// empty, the default subcommand `version` is run.
+func run(args []string) error {
+	cmd := "version"
+	if len(args) > 0 && len(args[0]) > 0 && args[0][0] != '-' {
+		cmd, args = args[0], args[1:]
+	}
+
+	switch cmd {
+	case "version":
+		return versionCmd(args)
+	case "watch":
+		return watchCmd(args)
+	case "unversion":
+		return unversionCmd(args)
+	case "generate":
+		return generateCmd(args)
+	case "migrate":
+		return migrateCmd(args)
+	}
+	return fmt.Errorf("Unknown command %q", cmd)
+}
+
+func versionCmd(args []string) error {
+	fs := flag.NewFlagSet("version", flag.ExitOnError)
+	src := fs.String("src", "src", "Source directory.")
+	dist := fs.String("dist", "dist", "Destination directory.")
+	savr := fs.Bool("savr", false, "Only match references to versioned files, with the literal matcher.")
+	dangling := fs.String("dangling", "warn", "Dangling reference policy, `warn`, `error` or `ignore`.")
+	report := fs.String("report", "", "If set, `file` to write the JSON report of the run to.")
+	check := fs.Bool("check", false, "Don't write.  Fail if dist is not what a fresh run would produce.")
+	lock := fs.String("lock", "off", "Lock file mode, `off`, `frozen` or `update`.")
+	lockFile := fs.String("lock-file", filever.LockFile, "Lock file.")
+	fs.Parse(args)
+
+	c := &filever.Config{Src: *src, Dist: *dist, UseSAVR: *savr, LockPath: *lockFile, Logger: logger()}
+	var err error
+	c.Dangling, err = parseDangling(*dangling)
+	if err != nil {
+		return err
+	}
+	c.Lock, err = parseLock(*lock)
+	if err != nil {
+		return err
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	if *check {
+		err = filever.CheckContext(ctx, c)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Dist is current.  Checked %d versioned files.\n", len(c.Info.VersionedFiles))
+		return nil
+	}
+	r, err := filever.Run(ctx, c)
+	if *report != "" {
+		b, err := json.MarshalIndent(r, "", "\t")
+		if err != nil {
+			return err
+		}
+		err = os.WriteFile(*report, b, 0644)
+		if err != nil {
+			return err
+		}
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Versioned %d files.  Updated %d files.\n", len(c.Info.VersionedFiles), len(c.Info.UpdatedFilePaths))
+	return nil
+}
+
+func watchCmd(args []string) error {
+	fs := flag.NewFlagSet("watch", flag.ExitOnError)
+	src := fs.String("src", "src", "Source directory.")
+	dist := fs.String("dist", "dist", "Destination directory.")
+	savr := fs.Bool("savr", false, "Only match references to versioned files, with the literal matcher.")
+	debounce := fs.Duration("debounce", filever.DefaultDebounce, "Wait for a burst of changes to end.")
+	watchDist := fs.Bool("watch-dist", false, "Also replace changed non-versioned files in dist.")
+	fs.Parse(args)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	c := &filever.Config{Src: *src, Dist: *dist, UseSAVR: *savr, Debounce: *debounce, WatchDist: *watchDist, Logger: logger()}
+	err := filever.Watch(ctx, c, func(cy *filever.Cycle) {
+		if cy.Err != nil {
+			fmt.Fprintln(os.Stderr, cy.Err)
+			return
+		}
+		fmt.Printf("Versioned %d files.  Updated %d files.\n", len(cy.Versioned), len(cy.Updated))
+	})
+	if errors.Is(err, context.Canceled) {
+		return nil
+	}
+	return err
+}
+
+func unversionCmd(args []string) error {
+	fs := flag.NewFlagSet("unversion", flag.ExitOnError)
+	dist := fs.String("dist", "dist", "Existing versioned directory.")
+	out := fs.String("out", "src", "Output directory for dummy versioned files.")
+	fs.Parse(args)
+
+	info, err := filever.Unversion(*dist, *out)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Unversioned %d files into %s.\n", len(info.Files), *out)
+	for f, refs := range info.Unresolved {
+		for _, r := range refs {
+			fmt.Printf("Unresolved reference in %s: %s\n", f, r)
+		}
+	}
+	return nil
+}
+
+func generateCmd(args []string) error {
+	fs := flag.NewFlagSet("generate", flag.ExitOnError)
+	src := fs.String("src", "src", "Source directory.")
+	dist := fs.String("dist", "dist", "Destination directory.")
+	pkg := fs.String("pkg", "main", "Package name of the generated file.")
+	out := fs.String("o", "assets_filever.go", "Generated file.")
+	fs.Parse(args)
+
+	c := &filever.Config{Src: *src, Dist: *dist}
+	err := filever.VersionReplace(c)
+	if err != nil {
+		return err
+	}
+	m, err := filever.NewManifest(c)
+	if err != nil {
+		return err
+	}
+	f, err := os.Create(*out)
+	if err != nil {
+		return err
+	}
+	err = filever.GenerateGo(f, *pkg, m)
+	if err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func migrateCmd(args []string) error {
+	fs := flag.NewFlagSet("migrate", flag.ExitOnError)
+	dir := fs.String("dir", "src", "Directory to migrate.")
+	fromDelim := fs.String("from-delim", filever.Delim, "Current delimiter.")
+	fromSize := fs.Int("from-size", filever.VersionSize, "Current version size.")
+	fromFormat := fs.String("from-format", "mid", "Current format, `mid` or `end`.")
+	toDelim := fs.String("to-delim", filever.Delim, "New delimiter.")
+	toSize := fs.Int("to-size", filever.VersionSize, "New version size.")
+	toFormat := fs.String("to-format", "mid", "New format, `mid` or `end`.")
+	dryRun := fs.Bool("dry-run", false, "Report without writing.")
+	fs.Parse(args)
+
+	from := filever.Scheme{Delim: *fromDelim, VersionSize: *fromSize}
+	to := filever.Scheme{Delim: *toDelim, VersionSize: *toSize}
+	var err error
+	from.Format, err = parseFormat(*fromFormat)
+	if err != nil {
+		return err
+	}
+	to.Format, err = parseFormat(*toFormat)
+	if err != nil {
+		return err
+	}
+
+	report, err := filever.Migrate(*dir, from, to, *dryRun)
+	if report != nil {
+		for old, n := range report.Renames {
+			fmt.Printf("Rename %s -> %s\n", old, n)
+		}
+		for f, n := range report.Rewrites {
+			fmt.Printf("Rewrite %d references in %s\n", n, f)
+		}
+		for _, c := range report.Conflicts {
+			fmt.Printf("Conflict: %s\n", c)
+		}
+	}
+	return err
+}
+
+// logger returns the logger for warnings, e.g. dangling references, to stderr.
+func logger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelWarn}))
+}
+
+func parseFormat(s string) (filever.Format, error) {
+	switch s {
+	case "mid":
+		return filever.MidVer, nil
+	case "end":
+		return filever.EndVer, nil
+	}
+	return 0, fmt.Errorf("Unknown format %q", s)
+}
+
+func parseDangling(s string) (filever.DanglingPolicy, error) {
+	switch s {
+	case "warn":
+		return filever.DanglingWarn, nil
+	case "error":
+		return filever.DanglingError, nil
+	case "ignore":
+		return filever.DanglingIgnore, nil
+	}
+	return 0, fmt.Errorf("Unknown dangling policy %q", s)
+}
+
+func parseLock(s string) (filever.LockMode, error) {
+	switch s {
+	case "off":
+		return filever.LockOff, nil
+	case "frozen":
+		return filever.LockFrozen, nil
+	case "update":
+		return filever.LockUpdate, nil
+	}
+	return 0, fmt.Errorf("Unknown lock mode %q", s)
+}
@@ -0,0 +1,99 @@
+package filever
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"golang.org/x/exp/slices"
+)
+
+// CollisionPolicy is the Version() behavior when the versions of two files
+// with different content collide, because VersionSize truncates digests.
+// Versions collide across the files of a run, and with a file of the same
+// version, e.g. a retained previous version, already in c.Dist.
+type CollisionPolicy int
+
+const (
+	// CollisionFail makes Version() return a *CollisionError.  Default.
+	CollisionFail CollisionPolicy = iota
+	// CollisionExtend extends the version of the colliding file, a digest
+	// character at a time, until it no longer collides.  Extended versions,
+	// e.g. `app~fv=4mIbJJPqZ.min.js`, are parsed by Populated() like any other
+	// version.
+	CollisionExtend
+)
+
+// versionOwner is the file owning a version in a run.
+//
+//	bare   - Bare path of the file, relative to c.Dist.
+//	digest - Canonical digest of the file.
+type versionOwner struct {
+	bare   string
+	digest string
+}
+
+// version returns the version of the file at bare, relative to c.Dist, with
+// the canonical digest: digest truncated to VersionSize, or longer if extended
+// by c.Collision.  dir is bare's directory in c.Dist, and existing are the
+// names of bare's versioned files in dir (see distVersions()).
+//
+// With CollisionExtend, a version extended by a previous run, i.e. in
+// existing, is kept even if the file it collided with has since been deleted,
+// e.g. as a previous version, so versions only change with content.
+func version(c *Config, bare, digest, dir string, existing []string) (v string, err error) {
+	if len(digest) < VersionSize {
+		return "", &ConfigError{Field: "HashAlg", Msg: fmt.Sprintf("digest of %s is shorter than VersionSize %d", bare, VersionSize)}
+	}
+	if c.Collision == CollisionExtend {
+		for size := len(digest); size > VersionSize; size-- {
+			fv, _ := genFileVer(bare, digest[:size], c)
+			if !slices.Contains(existing, filepath.Base(fv)) {
+				continue
+			}
+			other, err := collision(c, bare, digest, digest[:size], dir, existing)
+			if err != nil {
+				return "", err
+			}
+			if other == "" {
+				return digest[:size], nil
+			}
+		}
+	}
+	for size := VersionSize; size <= len(digest); size++ {
+		v = digest[:size]
+		other, err := collision(c, bare, digest, v, dir, existing)
+		if err != nil {
+			return "", err
+		}
+		if other == "" {
+			return v, nil
+		}
+		if c.Collision != CollisionExtend {
+			return "", &CollisionError{Name: "version " + v, Paths: []string{bare, other}}
+		}
+	}
+	return "", &CollisionError{Name: "digest " + digest, Paths: []string{bare}}
+}
+
+// collision returns the file, if any, that version v collides with.  The file
+// is either a bare path of the run, or a file in dir, relative to pwd.
+func collision(c *Config, bare, digest, v, dir string, existing []string) (other string, err error) {
+	if c.Info != nil {
+		if o, ok := c.Info.versions[v]; ok && o.digest != digest {
+			return o.bare, nil
+		}
+	}
+	fv, _ := genFileVer(bare, v, c)
+	if !slices.Contains(existing, filepath.Base(fv)) {
+		return "", nil
+	}
+	name := filepath.Join(dir, filepath.Base(fv))
+	d, _, err := HashFileCanonical(name, HashAlg)
+	if err != nil {
+		return "", fileOpErr("hash", name, err)
+	}
+	if d.String() != digest {
+		return name, nil
+	}
+	return "", nil
+}
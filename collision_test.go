@@ -0,0 +1,115 @@
+package filever
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+)
+
+// ExampleCollisionPolicy demonstrates a version colliding with a different
+// file of the same version in dist, e.g. a retained previous version, under
+// CollisionFail and CollisionExtend.
+func ExampleCollisionPolicy() {
+	err := os.RemoveAll(collisionDist)
+	if err != nil {
+		panic(err)
+	}
+	err = os.MkdirAll(collisionDist, 0755)
+	if err != nil {
+		panic(err)
+	}
+	// Different content with the version test_1.js will get.
+	err = os.WriteFile(collisionDist+"/test_1~fv=vPCb4GVO.js", []byte("// Colliding.\n"), 0644)
+	if err != nil {
+		panic(err)
+	}
+
+	err = VersionReplace(&Config{Src: dummySrc, Dist: collisionDist})
+	var ce *CollisionError
+	fmt.Println(errors.As(err, &ce))
+	fmt.Println(err)
+
+	c := &Config{Src: dummySrc, Dist: collisionDist, Collision: CollisionExtend, Retention: &Retention{}}
+	err = VersionReplace(c)
+	if err != nil {
+		panic(err)
+	}
+	fmt.Println(c.Info.PV["test_1.js"])
+	fmt.Println(Populated("test_1~fv=vPCb4GVOi.js").BarePath, Populated("test_1~fv=vPCb4GVOi.js").Version)
+
+	// Output:
+	// true
+	// Collision on version vPCb4GVO: test_1.js, test/collision/test_1~fv=vPCb4GVO.js
+	// vPCb4GVOi
+	// test_1.js vPCb4GVOi
+}
+
+// TestVersion_collision tests a version colliding with a different file of the
+// same run with the same truncated digest.
+func TestVersion_collision(t *testing.T) {
+	digest := "vPCb4GVOidk9y3TYHaTcZUE1ALtmFh71jxanQvpScYQ"
+	for _, tc := range []struct {
+		collision CollisionPolicy
+		want      string
+		err       string
+	}{
+		{CollisionFail, "", "Collision on version vPCb4GVO: test_1.js, other.js"},
+		{CollisionExtend, "vPCb4GVOi", ""},
+	} {
+		c := &Config{Collision: tc.collision, Info: &Info{versions: map[string]versionOwner{"vPCb4GVO": {bare: "other.js", digest: "vPCb4GVOxxxx"}}}}
+		v, err := version(c, "test_1.js", digest, "", nil)
+		if tc.err != "" {
+			var ce *CollisionError
+			if !errors.As(err, &ce) || err.Error() != tc.err {
+				t.Errorf("%v: err = %v, want %s", tc.collision, err, tc.err)
+			}
+			continue
+		}
+		if err != nil || v != tc.want {
+			t.Errorf("%v: version = %q, %v, want %q", tc.collision, v, err, tc.want)
+		}
+	}
+}
+
+// TestCollisionExtend_stable tests that, without Retention, a version extended
+// because of a colliding previous version, which is then deleted, is kept by
+// the next run.
+func TestCollisionExtend_stable(t *testing.T) {
+	lockPath := collisionStableDist + ".json"
+	for _, name := range []string{collisionStableDist, lockPath} {
+		err := os.RemoveAll(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	err := os.MkdirAll(collisionStableDist, 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	colliding := collisionStableDist + "/test_1~fv=vPCb4GVO.js"
+	err = os.WriteFile(colliding, []byte("// Colliding.\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, lock := range []LockMode{LockUpdate, LockFrozen} {
+		c := &Config{Src: dummySrc, Dist: collisionStableDist, Collision: CollisionExtend, Lock: lock, LockPath: lockPath}
+		err = VersionReplace(c)
+		if err != nil {
+			t.Fatalf("lock %v: %v", lock, err)
+		}
+		if v := c.Info.PV["test_1.js"]; v != "vPCb4GVOi" {
+			t.Errorf("lock %v: version = %s, want vPCb4GVOi", lock, v)
+		}
+		_, err = os.Stat(colliding)
+		if !os.IsNotExist(err) {
+			t.Errorf("lock %v: previous version %s not deleted: %v", lock, colliding, err)
+		}
+	}
+
+	err = Check(&Config{Src: dummySrc, Dist: collisionStableDist, Collision: CollisionExtend})
+	if err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,147 @@
+package filever
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+)
+
+// Extensions of pre-compressed siblings of versioned files, e.g.
+// `app~fv=4mIbJJPq.min.js.gz`.
+var (
+	GzipExt   = ".gz"
+	BrotliExt = ".br"
+)
+
+// Compress is the policy for writing pre-compressed siblings of versioned
+// files in `dist`, e.g. `app~fv=4mIbJJPq.min.js.gz` and
+// `app~fv=4mIbJJPq.min.js.br`, for static servers that serve them when
+// present.  Siblings are deleted alongside their versioned file.
+//
+//	Gzip        - Write gzip siblings.
+//	GzipLevel   - gzip level.  Default (0): gzip.BestCompression.
+//	Brotli      - Write brotli siblings.
+//	BrotliLevel - brotli level.  Default (0): brotli.BestCompression.
+//	MinSize     - Files smaller than MinSize bytes are not compressed.
+type Compress struct {
+	Gzip        bool
+	GzipLevel   int
+	Brotli      bool
+	BrotliLevel int
+	MinSize     int64
+}
+
+// WriteCompressed writes the compressed siblings of c.Info.VersionedFiles
+// according to c.Compress.  Siblings are written if missing, older than the
+// versioned file, or if the versioned file is in c.Info.UpdatedFilePaths, since
+// modification times may not advance on coarse-grained file systems.
+// WriteCompressed must be called after Replace(), which may update versioned
+// files.  Returns the written siblings, relative to pwd.
+func WriteCompressed(c *Config) (written []string, err error) {
+	if c.Compress == nil || c.Info == nil {
+		return nil, nil
+	}
+	updated := map[string]bool{}
+	for _, p := range c.Info.UpdatedFilePaths {
+		updated[filepath.Clean(p)] = true
+	}
+	for _, f := range c.Info.VersionedFiles {
+		name := filepath.Join(c.Dist, filepath.FromSlash(f))
+		fi, err := os.Stat(name)
+		if err != nil {
+			return written, err
+		}
+		if fi.Size() < c.Compress.MinSize {
+			continue
+		}
+
+		var b []byte
+		for _, s := range c.Compress.siblings() {
+			sfi, err := os.Stat(name + s.ext)
+			if err == nil && !updated[name] && !sfi.ModTime().Before(fi.ModTime()) {
+				continue
+			}
+			if b == nil {
+				b, err = os.ReadFile(name)
+				if err != nil {
+					return written, err
+				}
+			}
+			var buf bytes.Buffer
+			err = s.compress(&buf, b)
+			if err != nil {
+				return written, err
+			}
+			err = writeFile(name+s.ext, buf.Bytes(), 0644)
+			if err != nil {
+				return written, err
+			}
+			written = append(written, name+s.ext)
+		}
+	}
+	return written, nil
+}
+
+// sibling is a compressed sibling format.
+type sibling struct {
+	ext      string
+	compress func(w io.Writer, b []byte) error
+}
+
+// siblings returns the enabled sibling formats.
+func (cp *Compress) siblings() (s []sibling) {
+	if cp.Gzip {
+		level := cp.GzipLevel
+		if level == 0 {
+			level = gzip.BestCompression
+		}
+		s = append(s, sibling{GzipExt, func(w io.Writer, b []byte) error {
+			zw, err := gzip.NewWriterLevel(w, level)
+			if err != nil {
+				return err
+			}
+			_, err = zw.Write(b)
+			if err != nil {
+				return err
+			}
+			return zw.Close()
+		}})
+	}
+	if cp.Brotli {
+		level := cp.BrotliLevel
+		if level == 0 {
+			level = brotli.BestCompression
+		}
+		s = append(s, sibling{BrotliExt, func(w io.Writer, b []byte) error {
+			bw := brotli.NewWriterLevel(w, level)
+			_, err := bw.Write(b)
+			if err != nil {
+				return err
+			}
+			return bw.Close()
+		}})
+	}
+	return s
+}
+
+// isCompressedSibling reports whether the file name is a compressed sibling.
+func isCompressedSibling(name string) bool {
+	return strings.HasSuffix(name, GzipExt) || strings.HasSuffix(name, BrotliExt)
+}
+
+// removeCompressedSiblings removes the compressed siblings, if any, of the
+// file at name.
+func removeCompressedSiblings(name string) error {
+	for _, ext := range []string{GzipExt, BrotliExt} {
+		err := os.Remove(name + ext)
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
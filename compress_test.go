@@ -0,0 +1,135 @@
+package filever
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/andybalholm/brotli"
+)
+
+// ExampleWriteCompressed demonstrates writing gzip and brotli siblings of
+// versioned files and deleting siblings alongside old versions.
+func ExampleWriteCompressed() {
+	for _, d := range []string{compressSrc, compressDist} {
+		err := os.RemoveAll(d)
+		if err != nil {
+			panic(err)
+		}
+		err = os.MkdirAll(d, 0755)
+		if err != nil {
+			panic(err)
+		}
+	}
+	c := &Config{Src: compressSrc, Dist: compressDist, Compress: &Compress{Gzip: true, Brotli: true}}
+	for _, content := range []string{"console.log('release 1');", "console.log('release 2');"} {
+		err := os.WriteFile(compressSrc+"/app~fv=00000000.js", []byte(strings.Repeat(content, 10)), 0644)
+		if err != nil {
+			panic(err)
+		}
+		err = VersionReplace(c)
+		if err != nil {
+			panic(err)
+		}
+		f, err := ListFilesInPath(compressDist)
+		if err != nil {
+			panic(err)
+		}
+		fmt.Println(f)
+	}
+
+	name := compressDist + "/" + c.Info.VersionedFiles[0]
+	b, err := os.ReadFile(name)
+	if err != nil {
+		panic(err)
+	}
+	gz, err := os.Open(name + GzipExt)
+	if err != nil {
+		panic(err)
+	}
+	defer gz.Close()
+	zr, err := gzip.NewReader(gz)
+	if err != nil {
+		panic(err)
+	}
+	gzb, err := io.ReadAll(zr)
+	if err != nil {
+		panic(err)
+	}
+	br, err := os.Open(name + BrotliExt)
+	if err != nil {
+		panic(err)
+	}
+	defer br.Close()
+	brb, err := io.ReadAll(brotli.NewReader(br))
+	if err != nil {
+		panic(err)
+	}
+	fmt.Println(string(gzb) == string(b), string(brb) == string(b))
+
+	// Output:
+	// [app~fv=4EODwRx5.js app~fv=4EODwRx5.js.br app~fv=4EODwRx5.js.gz]
+	// [app~fv=A2MjEz0y.js app~fv=A2MjEz0y.js.br app~fv=A2MjEz0y.js.gz]
+	// true true
+}
+
+// TestWriteCompressed_updated tests that siblings of files updated by Replace()
+// are regenerated even when the sibling is not older than the updated file.
+func TestWriteCompressed_updated(t *testing.T) {
+	err := os.RemoveAll(compressUpdatedDist)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.MkdirAll(compressUpdatedDist, 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := &Config{Src: dummySrc, Dist: compressUpdatedDist, Compress: &Compress{Gzip: true}}
+	err = VersionReplace(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Simulate Replace() updating a versioned file within the modification
+	// time resolution of its sibling.
+	name := filepath.Join(compressUpdatedDist, c.Info.VersionedFiles[0])
+	want := []byte("console.log('updated');")
+	err = os.WriteFile(name, want, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	future := time.Now().Add(time.Hour)
+	err = os.Chtimes(name+GzipExt, future, future)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Info.UpdatedFilePaths = []string{name}
+	written, err := WriteCompressed(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(written) != 1 || written[0] != name+GzipExt {
+		t.Fatalf("written = %v, want [%s]", written, name+GzipExt)
+	}
+	gz, err := os.Open(name + GzipExt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer gz.Close()
+	zr, err := gzip.NewReader(gz)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := io.ReadAll(zr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != string(want) {
+		t.Errorf("sibling = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,151 @@
+package filever
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// ExampleVersionReplaceContext demonstrates that a cancelled run stops
+// between files with ctx.Err(), and that running again completes it.
+func ExampleVersionReplaceContext() {
+	err := os.RemoveAll(contextDist)
+	if err != nil {
+		panic(err)
+	}
+	err = os.MkdirAll(contextDist, 0755)
+	if err != nil {
+		panic(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	c := &Config{Src: dummySrc, Dist: contextDist}
+	err = VersionReplaceContext(ctx, c)
+	fmt.Println(errors.Is(err, context.Canceled))
+	files, err := ListFilesInPath(contextDist)
+	if err != nil {
+		panic(err)
+	}
+	fmt.Println(len(files))
+
+	err = VersionReplaceContext(context.Background(), c)
+	if err != nil {
+		panic(err)
+	}
+	files, err = ListFilesInPath(contextDist)
+	if err != nil {
+		panic(err)
+	}
+	fmt.Println(files)
+
+	// Output:
+	// true
+	// 0
+	// [test_1~fv=vPCb4GVO.js test_2~fv=BOl7h9TM.js]
+}
+
+// TestVersionReplaceContext_cancel tests that every reference in dist
+// resolves after a run cancelled while versioning, between Version() and
+// Replace(), or while replacing.
+func TestVersionReplaceContext_cancel(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		cancel func(Event) bool // Reports whether to cancel on the event.
+	}{
+		{"version", func(e Event) bool { c, ok := e.(FileCopied); return ok && strings.Contains(c.To, "a~fv=") }},
+		{"between", func(e Event) bool { c, ok := e.(FileCopied); return ok && strings.Contains(c.To, "b~fv=") }},
+		{"replace", func(e Event) bool { _, ok := e.(FileRewritten); return ok }},
+	} {
+		for _, d := range []string{contextCancelSrc, contextCancelDist} {
+			err := os.RemoveAll(d)
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = os.MkdirAll(d, 0755)
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+		err := os.WriteFile(contextCancelDist+"/index.html", []byte(`<script src="a~fv=00000000.js"></script>`), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+		release := func(n int) {
+			err := os.WriteFile(contextCancelSrc+"/a~fv=00000000.js", []byte(fmt.Sprintf("import './b~fv=00000000.js'; // Release %d.", n)), 0644)
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = os.WriteFile(contextCancelSrc+"/b~fv=00000000.js", []byte(fmt.Sprintf("// Release %d.", n)), 0644)
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+		release(1)
+		err = VersionReplace(&Config{Src: contextCancelSrc, Dist: contextCancelDist})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		release(2)
+		ctx, cancel := context.WithCancel(context.Background())
+		c := &Config{Src: contextCancelSrc, Dist: contextCancelDist, Observer: ObserverFunc(func(e Event) {
+			if tc.cancel(e) {
+				cancel()
+			}
+		})}
+		err = VersionReplaceContext(ctx, c)
+		cancel()
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("%s: err = %v, want context.Canceled", tc.name, err)
+		}
+		checkRefsResolve(t, tc.name, contextCancelDist)
+
+		c.Observer = nil
+		err = VersionReplace(c)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		checkRefsResolve(t, tc.name, contextCancelDist)
+		files, err := ListFilesInPath(contextCancelDist)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(files) != 3 {
+			t.Errorf("%s: previous versions not deleted: %v", tc.name, files)
+		}
+	}
+}
+
+// checkRefsResolve checks that every reference in the files in dir, which are
+// relative to their file, resolves to a file in dir.  Dummy versioned
+// references, in new versioned files not yet replaced and not yet referenced,
+// are skipped.
+func checkRefsResolve(t *testing.T, name, dir string) {
+	t.Helper()
+	files, err := ListFilesInPath(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	reg := regexp.MustCompile(FileVerPathReg)
+	for _, f := range files {
+		b, err := os.ReadFile(filepath.Join(dir, f))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, ref := range reg.FindAllString(string(b), -1) {
+			if Populated(ref).Version == DummyVersion() {
+				continue
+			}
+			_, err := os.Stat(filepath.Join(dir, filepath.Dir(f), ref))
+			if err != nil {
+				t.Errorf("%s: %s: reference %s does not resolve", name, f, ref)
+			}
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package filever
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// DanglingPolicy is the Replace() behavior for dangling references, which are
+// references to versioned files not in c.Info.PV, e.g. `test_3~fv=00000000.js`
+// when `test_3.js` was not versioned.  Regardless of policy, dangling
+// references are reported in Info.DanglingRefs.  Dangling references are
+// replaced with the dummy version, except with DanglingError, where files with
+// dangling references are not written.
+type DanglingPolicy int
+
+const (
+	// DanglingWarn logs a warning to Config.Logger for each dangling
+	// reference.  Default.
+	DanglingWarn DanglingPolicy = iota
+	// DanglingError makes Replace() return an error listing every dangling
+	// reference, e.g. to fail a CI build.  Files with dangling references are
+	// left unchanged.
+	DanglingError
+	// DanglingIgnore ignores dangling references.
+	DanglingIgnore
+)
+
+// DanglingRef is a dangling reference found by Replace().
+//
+//	Path   - File containing the reference, relative to pwd.
+//	Line   - Line of the reference, starting at 1.
+//	Column - Column, in bytes, of the reference, starting at 1.
+//	Ref    - The reference, e.g. `./test_3~fv=00000000.js`.
+type DanglingRef struct {
+	Path   string
+	Line   int
+	Column int
+	Ref    string
+}
+
+// String returns the dangling reference as `path:line:column: ref`.
+func (d DanglingRef) String() string {
+	return fmt.Sprintf("%s:%d:%d: %s", d.Path, d.Line, d.Column, d.Ref)
+}
+
+// findDanglingRefs returns the dangling references in b, the content of the
+// file at path, using c.refs.
+func findDanglingRefs(c *Config, path string, b []byte) (refs []DanglingRef) {
+	for _, m := range c.refs.FindAllIndex(b, -1) {
+		ref := string(b[m[0]:m[1]])
+		if _, dummied, _ := currentRef(c, path, ref); !dummied {
+			continue
+		}
+		line, col := lineCol(b, m[0])
+		refs = append(refs, DanglingRef{Path: path, Line: line, Column: col, Ref: ref})
+	}
+	return refs
+}
+
+// lineCol returns the line and column, in bytes, both starting at 1, of the
+// offset i in b.
+func lineCol(b []byte, i int) (line, col int) {
+	line = bytes.Count(b[:i], []byte("\n")) + 1
+	col = i - (bytes.LastIndexByte(b[:i], '\n') + 1) + 1
+	return line, col
+}
+
+// danglingErr returns a *DanglingRefError for c.Info.DanglingRefs if
+// c.Dangling is DanglingError.
+func danglingErr(c *Config) error {
+	if c.Dangling != DanglingError || len(c.Info.DanglingRefs) == 0 {
+		return nil
+	}
+	return &DanglingRefError{Refs: c.Info.DanglingRefs}
+}
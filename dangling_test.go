@@ -0,0 +1,45 @@
+package filever
+
+import (
+	"fmt"
+	"os"
+)
+
+// danglingHTML refers to `missing.js`, which is not a versioned file.
+var danglingHTML = "<html>\n<script src=\"./missing~fv=00000000.js\"></script>\n"
+
+// ExampleReplace_dangling demonstrates failing on dangling references with
+// DanglingError.  `index.html` refers to `./missing~fv=00000000.js`, and
+// `missing.js` is not a versioned file, so `index.html` is not written.
+func ExampleReplace_dangling() {
+	err := os.RemoveAll(danglingDist)
+	if err != nil {
+		panic(err)
+	}
+	err = os.MkdirAll(danglingDist, 0755)
+	if err != nil {
+		panic(err)
+	}
+	err = os.WriteFile(danglingDist+"/index.html", []byte(danglingHTML+"<script src=\"./test_1~fv=00000000.js\"></script>\n"), 0644)
+	if err != nil {
+		panic(err)
+	}
+
+	c := &Config{Src: dummySrc, Dist: danglingDist, Dangling: DanglingError}
+	err = VersionReplace(c)
+	fmt.Println(err)
+	fmt.Println(c.Info.DanglingRefs)
+	PrintFile(danglingDist + "/index.html")
+
+	// Output:
+	// 1 dangling references:
+	// test/dangling/index.html:2:14: ./missing~fv=00000000.js
+	// [test/dangling/index.html:2:14: ./missing~fv=00000000.js]
+	// File test/dangling/index.html:
+	// ////////////////
+	// <html>
+	// <script src="./missing~fv=00000000.js"></script>
+	// <script src="./test_1~fv=00000000.js"></script>
+	//
+	// ////////////////
+}
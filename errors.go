@@ -0,0 +1,108 @@
+package filever
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Errors returned by FileVer are of the following types, usable with
+// errors.As.  Functions that process many files, e.g. Version() and Replace(),
+// continue after a file fails and return all errors joined by errors.Join.
+
+// ConfigError is an invalid or missing setting, in Config or in a package
+// variable such as FileVerPathReg.
+//
+//	Field - The setting, e.g. `c.Info`.
+//	Msg   - What is wrong, e.g. `must be set`.
+//	Err   - Underlying error, if any.
+type ConfigError struct {
+	Field string
+	Msg   string
+	Err   error
+}
+
+func (e *ConfigError) Error() string {
+	s := e.Field + " " + e.Msg
+	if e.Err != nil {
+		s += ": " + e.Err.Error()
+	}
+	return s
+}
+
+func (e *ConfigError) Unwrap() error { return e.Err }
+
+// FileOpError is a failed operation on a file.
+//
+//	Op   - Operation, e.g. `read`, `write`, `hash`, or `remove`.
+//	Path - Path of the file, relative to pwd.
+//	Err  - Underlying error.
+type FileOpError struct {
+	Op   string
+	Path string
+	Err  error
+}
+
+func (e *FileOpError) Error() string { return e.Op + " " + e.Path + ": " + e.Err.Error() }
+
+func (e *FileOpError) Unwrap() error { return e.Err }
+
+// fileOpErr returns a *FileOpError for err, or nil if err is nil.
+func fileOpErr(op, path string, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &FileOpError{Op: op, Path: path, Err: err}
+}
+
+// CollisionError is multiple paths that resolve to the same name, e.g. two
+// versions of the same bare path, or two bare paths generating the same Go
+// constant.
+//
+//	Name  - The name collided on, e.g. `bare path app.js`.
+//	Paths - The colliding paths.
+type CollisionError struct {
+	Name  string
+	Paths []string
+}
+
+func (e *CollisionError) Error() string {
+	return fmt.Sprintf("Collision on %s: %s", e.Name, strings.Join(e.Paths, ", "))
+}
+
+// DanglingRefError is returned by Replace() for dangling references when
+// Config.Dangling is DanglingError.  See DanglingRef.
+type DanglingRefError struct {
+	Refs []DanglingRef
+}
+
+func (e *DanglingRefError) Error() string {
+	s := make([]string, len(e.Refs))
+	for i, d := range e.Refs {
+		s[i] = d.String()
+	}
+	return fmt.Sprintf("%d dangling references:\n%s", len(s), strings.Join(s, "\n"))
+}
+
+// MigrateError is returned by Migrate() if migrating has conflicts.  See
+// MigrateReport.
+type MigrateError struct {
+	Conflicts []string
+}
+
+func (e *MigrateError) Error() string {
+	return fmt.Sprintf("%d migrate conflicts:\n%s", len(e.Conflicts), strings.Join(e.Conflicts, "\n"))
+}
+
+// CheckError is returned by Check() if c.Dist differs from what
+// VersionReplace() would produce.  See CheckDiff.
+type CheckError struct {
+	Diffs []CheckDiff
+}
+
+func (e *CheckError) Error() string {
+	s := make([]string, len(e.Diffs))
+	for i, d := range e.Diffs {
+		s[i] = d.String()
+	}
+	return fmt.Sprintf("%d differences from a fresh run:\n%s", len(s), strings.Join(s, "\n"))
+}
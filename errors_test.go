@@ -0,0 +1,35 @@
+package filever
+
+import (
+	"errors"
+	"fmt"
+	"os"
+)
+
+// ExampleDanglingRefError demonstrates inspecting typed errors with errors.As.
+func ExampleDanglingRefError() {
+	err := Replace(&Config{Dist: dummyDist})
+	var ce *ConfigError
+	fmt.Println(errors.As(err, &ce), ce.Field)
+
+	err = os.MkdirAll(danglingDist, 0755)
+	if err != nil {
+		panic(err)
+	}
+	err = os.WriteFile(danglingDist+"/index.html", []byte(danglingHTML), 0644)
+	if err != nil {
+		panic(err)
+	}
+	c := &Config{Src: dummySrc, Dist: danglingDist, Dangling: DanglingError}
+	err = VersionReplace(c)
+	var de *DanglingRefError
+	if errors.As(err, &de) {
+		for _, r := range de.Refs {
+			fmt.Println(r.Path, r.Line, r.Column)
+		}
+	}
+
+	// Output:
+	// true c.Info
+	// test/dangling/index.html 2 14
+}
@@ -3,12 +3,16 @@
 package filever
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"io/fs"
+	"log/slog"
 	"os"
 	"path/filepath"
 	"regexp"
 	"strings"
+	"time"
 
 	"github.com/cyphrme/coze"
 	path "github.com/cyphrme/path"
@@ -38,32 +42,73 @@ var VerAnySizeRegex = regexp.QuoteMeta(Delim) + `[0-9A-Za-z_-]*`
 var VerRegexC *regexp.Regexp
 var VerAnySizeRegexC *regexp.Regexp
 
-// FileVerPathReg "midVer" format and should match any path in source files that
-// includes a fileVer. The path regex should be delimited by non-path
-// characters, like `"`.  This regex including "startPath", e.g. `../`.
-//
-// Currently, this regex is very simple and doesn't support all valid paths.
-var FileVerPathReg = `[0-9A-Za-z_\-\/]*~fv=[0-9A-Za-z_\-]*.[0-9A-Za-z_\-.]*`
+// startPathRegC matches the start path of a reference, e.g. `../`.
+var startPathRegC = regexp.MustCompile(`^[\/\.]*`)
+
+// FileVerPathReg matches "midVer" references to versioned files in source
+// files, including the start path, e.g. `../`, which is needed to resolve the
+// reference, and the query and fragment, e.g.
+// `../e/app~fv=4mIbJJPq.min.js?v=1#top`.  See the reference grammar in ref.go
+// and resolveRef().
+var FileVerPathReg = refDirReg + refNameReg + `*` + regexp.QuoteMeta(Delim) + `[0-9A-Za-z_\-]*` + refExtReg + refQueryReg
 
 // HashAlg is the hash alg used for versioning.
 var HashAlg = coze.SHA256
 
 // Config holds the settings for operating the main FileVer functions.
 //
-//	Src      - Source directory starting point.  Must be nil for SrcFiles.
-//	SrcFiles - Manually provided src files.  Will be set to Src's files if nil (default behavior).
-//	SrcReg   - Compiled Regex used to search source files for FileVersions for Replace().
-//	             May be set by external program.
-//	Dist     - destination directory.  Default: Output will be on one level.
+//	Src          - Source directory starting point.  Must be nil for SrcFiles.
+//	SrcFiles     - Manually provided src files.  Will be set to Src's files if nil (default behavior).
+//	SrcReg       - Compiled Regex used to search source files for FileVersions for Replace().
+//	                 May be set by external program.
+//	UseSAVR      - If true and SrcReg is not set, Replace() only matches references to versioned files, with a literal matcher.  See literalMatcher.
+//	Dist         - destination directory.  Default: Output will be on one level.
+//	Manifest     - If true, VersionReplace() writes a manifest into c.Dist.  See WriteManifest().
+//	History      - If true, VersionReplace() appends the release to the history ledger in c.Dist.  See Rollback().
+//	Retention    - If set, previous versions are retained in c.Dist until Prune().
+//	Debounce     - Duration Watch() waits for a burst of changes to end.  Default: DefaultDebounce.
+//	WatchDist    - If true, Watch() also watches c.Dist for changes to non-versioned files.
+//	Transformers - Run on src files before hashing.  See Transform().
+//	Compress     - If set, VersionReplace() writes compressed siblings of versioned files.  See WriteCompressed().
+//	Include      - doublestar patterns, relative to c.Src or c.Dist, of files to walk.  Default: all files.
+//	Exclude      - doublestar patterns, relative to c.Src or c.Dist, of files and directories not to walk.  See also IgnoreFile.
+//	TextExts     - Extensions of text files scanned by Replace().  Other files are skipped unless their content is text.  Default: DefaultTextExts.
+//	Dangling     - Replace() behavior for references to versioned files not in c.Info.PV.  Default: DanglingWarn.
+//	URLPrefixes  - URL prefixes of references, e.g. `/static/`, mapped to directories in c.Dist.  See URLPrefix.
+//	Lock         - Version() behavior for the lock file.  Default: LockOff.
+//	LockPath     - Path, relative to pwd, of the lock file.  Default: LockFile.
+//	Collision    - Version() behavior for files whose truncated digests collide.  Default: CollisionFail.
+//	Logger       - Logger of events.  Warnings, e.g. dangling references, are logged at slog.LevelWarn.  Default: warnings to slog.Default().
+//	Observer     - If set, receives events.  See Event.
 type Config struct {
-	Src      string
-	SrcFiles []string
-	SrcReg   *regexp.Regexp
-	Dist     string
-	UseSAVR  bool
+	Src          string
+	SrcFiles     []string
+	SrcReg       *regexp.Regexp
+	Dist         string
+	UseSAVR      bool
+	Manifest     bool
+	History      bool
+	Retention    *Retention
+	Debounce     time.Duration
+	WatchDist    bool
+	Transformers []Transformer
+	Compress     *Compress
+	Include      []string
+	Exclude      []string
+	TextExts     []string
+	Dangling     DanglingPolicy
+	URLPrefixes  []URLPrefix
+	Lock         LockMode
+	LockPath     string
+	Collision    CollisionPolicy
+	Logger       *slog.Logger
+	Observer     Observer
 
 	// Use Internally
-	Info *Info
+	Info   *Info
+	report *Report    // Report of the run, if recording.  See Run().
+	lock   *Lock      // Lock of the run, unless c.Lock is LockOff.
+	refs   refMatcher // Matcher of references in source files.  See genSrcReg().
 }
 
 type Info struct {
@@ -71,16 +116,10 @@ type Info struct {
 	//`"test_1.js":"4WYoW0MN"``
 	PV map[string]string
 
-	// SAVR "Search All Versioned, Regex". Regex that matches all filevers at once
-	// in the current directory. This results in a large regex, but allows single
-	// pass searching for each file.  The downside is that a match matches all
-	// "versions", so the match still needs to be matched to a single version
-	// after this regex returns a match.
-	// Example of the resulting regex:
-	// `(app\.min\.js\~fv=[0-9A-Za-z_-]*)|(coze\.min\.js\~fv=[0-9A-Za-z_-]*)`
-	// SAVR needs to be regenerated for each subdirectory.
-	// key: current directory with root being "" and no preceding `/`.  E.g. `subdir/subdir2`
-	// Value: current directory SAVR.
+	// SAVR "Search All Versioned, Regex" was the regex, with an alternative per
+	// versioned file, used for c.UseSAVR.
+	//
+	// Deprecated: c.UseSAVR uses a literal matcher and SAVR is not set.
 	SAVR string
 
 	// Versioned files (relatively pathed to c.Dist).  Generated when calling
@@ -104,11 +143,22 @@ type Info struct {
 	// relative to pwd.
 	UpdatedFilePaths []string
 
-	// Used for processing
-	// Current path should be relative to dist or src, not including dist, and not
-	// from pwd.
-	CurrentPath    string `json:"-"`
-	CurrentMatches int    `json:"-"`
+	// DanglingRefs are references to versioned files not in PV found by
+	// `Replace()`.  See Config.Dangling.
+	DanglingRefs []DanglingRef
+
+	// SkippedFilePaths are files that were skipped by `Replace()` because they
+	// are not text, e.g. images and fonts.  See Config.TextExts.  Paths are
+	// relative to pwd.
+	SkippedFilePaths []string
+
+	// versions are the owners of the versions of the run, for detecting
+	// collisions.  See Config.Collision.
+	versions map[string]versionOwner
+
+	// previous are the previous versions of the run in c.Dist, relative to pwd,
+	// to be deleted once references are replaced.  See deletePrevious().
+	previous []string
 }
 
 func init() {
@@ -116,117 +166,330 @@ func init() {
 	VerAnySizeRegexC = regexp.MustCompile(VerAnySizeRegex)
 }
 
-// VersionReplace see notes on Version() and Replace()
+// VersionReplace see notes on Version() and Replace().  If c.Compress is set,
+// also writes compressed siblings.  If c.Manifest is true, also writes the
+// manifest.  If c.History is true, also appends the release to the history
+// ledger.
 func VersionReplace(c *Config) (err error) {
-	err = Version(c)
+	return VersionReplaceContext(context.Background(), c)
+}
+
+// VersionReplaceContext is VersionReplace() stopping when ctx is done.  See
+// VersionContext() and ReplaceContext().  Previous versions are deleted only
+// after Replace() finished without cancellation, so references in c.Dist
+// always resolve.
+func VersionReplaceContext(ctx context.Context, c *Config) (err error) {
+	err = versionContext(ctx, c)
+	if err != nil {
+		return err
+	}
+	err = ReplaceContext(ctx, c)
+	if err != nil {
+		return err
+	}
+	err = ctx.Err()
 	if err != nil {
 		return err
 	}
-	return Replace(c)
+	err = deletePrevious(c)
+	if err != nil {
+		return err
+	}
+	start := time.Now()
+	_, err = WriteCompressed(c)
+	phase(c, "compress", start)
+	if err != nil {
+		return err
+	}
+	if c.Manifest {
+		start = time.Now()
+		err = WriteManifest(c)
+		phase(c, "manifest", start)
+		if err != nil {
+			return err
+		}
+	}
+	if c.History {
+		start = time.Now()
+		_, err = AppendHistory(c, "")
+		phase(c, "history", start)
+	}
+	return err
 }
 
 // Version versions all dummied FileVer files in input directory including
 // subdirectories, copies them into c.dist, and removes any existing versions.
+// Versions are the canonical digest of the file (see Canonical()), so the
+// version is reproducible from either `src` or `dist`.
 //
 // Populates c.Info.PV and c.Info.VersionedFiles.
 func Version(c *Config) (err error) {
+	return VersionContext(context.Background(), c)
+}
+
+// VersionContext is Version() stopping when ctx is done.  Cancellation is
+// checked between files, so each file is either fully versioned or untouched,
+// and ctx.Err() is returned.  Previous versions are deleted only once all
+// files are versioned.  Running Version again completes a cancelled run.
+func VersionContext(ctx context.Context, c *Config) (err error) {
+	err = versionContext(ctx, c)
+	if err != nil {
+		return err
+	}
+	return deletePrevious(c)
+}
+
+// versionContext is VersionContext() without deleting previous versions, which
+// are collected in c.Info.previous.
+func versionContext(ctx context.Context, c *Config) (err error) {
 	c.Info = new(Info)
 	c.Info.PV = map[string]string{}
+	c.Info.versions = map[string]versionOwner{}
+	err = startLock(c)
+	if err != nil {
+		return err
+	}
 
+	start := time.Now()
+	err = transform(ctx, c)
+	phase(c, "transform", start)
+	if err != nil {
+		return err
+	}
+
+	start = time.Now()
+	defer phase(c, "version", start)
 	if c.SrcFiles == nil {
-		c.SrcFiles, err = ExistingVersionedFiles(c.Src)
+		c.SrcFiles, err = srcVersionedFiles(ctx, c)
 		if err != nil {
 			return err
 		}
 	}
 
 	c.Info.VersionedFiles = []string{} // Files without paths.
+	var errs []error                   // Failing files don't stop versioning other files.
 	for _, path := range c.SrcFiles {
-		file, err := FileToFileVerOutputDelete(path, c)
-		if err != nil {
+		if err := ctx.Err(); err != nil {
 			return err
 		}
-		c.Info.VersionedFiles = append(c.Info.VersionedFiles, file)
-		p := Populated(file)
-		c.Info.PV[p.BarePath] = p.Version // e.g. "e/app.min.js" = "4WYoW0MN"
+		_, err := versionFile(c, path)
+		errs = append(errs, err)
+	}
+	err = errors.Join(errs...)
+	if err != nil {
+		return err
+	}
+	return endLock(c)
+}
+
+// deletePrevious deletes the previous versions collected in c.Info.previous,
+// unless they became the current version since.
+func deletePrevious(c *Config) error {
+	var errs []error
+	for _, name := range c.Info.previous {
+		if !isPreviousVersion(c, name) {
+			continue
+		}
+		errs = append(errs, removeVersion(c, name))
+	}
+	c.Info.previous = nil
+	return errors.Join(errs...)
+}
+
+// removeVersion deletes the versioned file at name, relative to pwd, and its
+// compressed siblings.  A missing file is not an error.
+func removeVersion(c *Config, name string) error {
+	err := os.Remove(name)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fileOpErr("remove", name, err)
+	}
+	err = removeCompressedSiblings(name)
+	if err != nil {
+		return err
 	}
+	emit(c, VersionDeleted{Path: name})
 	return nil
 }
 
+// versionFile versions the file at path, relative to c.Src, and updates
+// c.Info.PV and c.Info.VersionedFiles.  Returns the versioned file, relative
+// to c.Dist.
+func versionFile(c *Config, path string) (file string, err error) {
+	file, err = FileToFileVerOutputDelete(path, c)
+	if err != nil {
+		return "", err
+	}
+	p := Populated(file)
+	if old, ok := c.Info.PV[p.BarePath]; ok { // Remove previous version.
+		if old != p.Version && c.Info.versions[old].bare == p.BarePath {
+			delete(c.Info.versions, old)
+		}
+		i := slices.IndexFunc(c.Info.VersionedFiles, func(f string) bool {
+			return Populated(f).BarePath == p.BarePath
+		})
+		if i != -1 {
+			c.Info.VersionedFiles = slices.Delete(c.Info.VersionedFiles, i, i+1)
+		}
+	}
+	c.Info.VersionedFiles = append(c.Info.VersionedFiles, file)
+	c.Info.PV[p.BarePath] = p.Version // e.g. "e/app.min.js" = "4WYoW0MN"
+	return file, nil
+}
+
 // Replace updates all source file references to versioned files with the
 // current version. c.Info.PV and c.Info.VersionedFiles must be set correctly.
+// Dangling references, to versioned files not in c.Info.PV, are reported in
+// c.Info.DanglingRefs and handled according to c.Dangling.
 func Replace(c *Config) (err error) {
+	return ReplaceContext(context.Background(), c)
+}
+
+// ReplaceContext is Replace() stopping when ctx is done.  Cancellation is
+// checked while reading files, before any file is written, so c.Dist is either
+// fully updated or untouched, and ctx.Err() is returned.
+func ReplaceContext(ctx context.Context, c *Config) (err error) {
+	defer phase(c, "replace", time.Now())
 	//fmt.Printf("\nReplace Config  %+v Info: %+v\n", c, c.Info)
 	if c.Info == nil {
-		return fmt.Errorf("c.Info must be set.")
-	}
-
-	genSrcReg(c)
-
-	// PathedVersionedReplace is called on each match.  Input is the matched string.
-	// Variable "in" is file name, without the relative subdirectory path.
-	var PathedVersionedReplace = func(in []byte) []byte {
-		//fmt.Printf("PathedVersionedReplace - match: %s\n", in)
-		c.Info.CurrentMatches++
-		// Match will include version.  Get bare file name without versioning.
-		bare := VerAnySizeRegexC.ReplaceAllString(string(in), "")
-		// If the file has a start path, e.g. `../` in
-		// `../test_1~fv=SgfqvMD3.min.js` is the startPath.
-		// To find the version in PV, must remove startPath (characters [".","/"]).
-		startPathReg := regexp.MustCompile(`^[\/\.]*`)
-		startPath := startPathReg.FindString(bare)
-		woStartPath := startPathReg.ReplaceAllString(bare, "")
-		version := c.Info.PV[woStartPath]
-		//fmt.Printf("bare: %s version: %s woStartPath: %s\n", bare, version, woStartPath)
-		fv, dummied := genFileVer(woStartPath, version, c)
-		if dummied {
-			fmt.Printf("***WARNING*** Digest empty or too small for %s\n", woStartPath)
-		}
-		fv = startPath + fv // TODO this can probably be fixed in genFileVer
-		return []byte(fv)
+		return &ConfigError{Field: "c.Info", Msg: "must be set"}
+	}
 
+	err = genSrcReg(c)
+	if err != nil {
+		return err
 	}
+	c.Info.DanglingRefs = nil
 
+	f, err := newFilter(c, c.Dist)
+	if err != nil {
+		return err
+	}
 	// Walk walks all files (recursively) in directory. Variable `path` is
 	// relative to to running location of the program (program root dir).
-	var walk = func(path string, d fs.DirEntry, err error) error {
-		if err != nil {
-			return err
+	// Failing files don't stop the walk.
+	var errs []error
+	var rewrites []*rewrite
+	err = walkFiles(ctx, c.Dist, f, func(path, _ string, _ fs.DirEntry) error {
+		w, err := readReplace(c, path)
+		if w != nil {
+			rewrites = append(rewrites, w)
 		}
-		//fmt.Printf("\nWalking path: %s\n", path)
+		errs = append(errs, err)
+		return nil
+	})
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	// Once writing starts, all files are written, regardless of ctx.
+	for _, w := range rewrites {
+		errs = append(errs, w.write(c))
+	}
+	return errors.Join(append(errs, err, danglingErr(c))...)
+}
 
-		if d.IsDir() {
-			return nil
-		}
-		c.Info.CurrentMatches = 0
+// rewrite is a file updated by Replace(), to be written.
+type rewrite struct {
+	path     string
+	b        []byte
+	replaces int
+}
 
-		//fmt.Printf("walk - path: %s; d: %+v, c.Info %+v\n", path, d, c.Info)
-		read, err := os.ReadFile(path)
-		if err != nil {
-			return err
-		}
-		replaced := c.SrcReg.ReplaceAllFunc(read, PathedVersionedReplace)
-		//fmt.Printf("Replaced contents: %s\n", replaced)
-		if c.Info.CurrentMatches > 0 { // Only Write out on match.
+// write writes the updated file.
+func (w *rewrite) write(c *Config) error {
+	c.Info.TotalSourceReplaces += w.replaces
+	c.Info.UpdatedFilePaths = append(c.Info.UpdatedFilePaths, w.path)
+	//fmt.Printf("matches: %d.  Writing updated file: %s\n", matches, path)
+	err := writeFile(w.path, w.b, 0644)
+	if err != nil {
+		return fileOpErr("write", w.path, err)
+	}
+	emit(c, FileRewritten{Path: w.path, Replaces: w.replaces})
+	return nil
+}
 
-			if slices.Equal(read, replaced) { // Don't write out if there are no updates.
-				c.Info.CheckedFilePaths = append(c.Info.CheckedFilePaths, path)
-				return nil
-			}
+// replaceFile updates references to versioned files in the file at path, which
+// is relative to pwd.  c.refs must be set.
+func replaceFile(c *Config, path string) (err error) {
+	w, err := readReplace(c, path)
+	if w == nil || err != nil {
+		return err
+	}
+	return w.write(c)
+}
 
-			c.Info.TotalSourceReplaces += c.Info.CurrentMatches
-			c.Info.UpdatedFilePaths = append(c.Info.UpdatedFilePaths, path)
-			//fmt.Printf("info.CurrentMatches: %d.  Writing updated file: %s\n", c.Info.CurrentMatches, path)
-			err = os.WriteFile(path, replaced, 0)
-			if err != nil {
-				return err
+// readReplace reads the file at path, which is relative to pwd, and returns
+// its rewrite, or nil if the file is current.  c.refs must be set.
+func readReplace(c *Config, path string) (w *rewrite, err error) {
+	if isPreviousVersion(c, path) { // Retained previous versions keep their references.
+		return nil, nil
+	}
+	if isCompressedSibling(path) { // Written from their versioned file by WriteCompressed().
+		return nil, nil
+	}
+	//fmt.Printf("replaceFile - path: %s, c.Info %+v\n", path, c.Info)
+	read, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fileOpErr("read", path, err)
+	}
+	if !isText(c, path, read) { // Never write to binary files, e.g. images and fonts.
+		c.Info.SkippedFilePaths = append(c.Info.SkippedFilePaths, path)
+		if c.report != nil {
+			c.report.Skipped = append(c.report.Skipped, path)
+		}
+		return nil, nil
+	}
+	replaced, matches, dangling := replaceRefs(c, path, read)
+	if dangling {
+		refs := findDanglingRefs(c, path, read)
+		for _, r := range refs {
+			emit(c, ReferenceUnresolved{DanglingRef: r, Policy: c.Dangling})
+		}
+		c.Info.DanglingRefs = append(c.Info.DanglingRefs, refs...)
+		if c.Dangling == DanglingError { // Replace() fails.  Don't write dummied references.
+			return nil, nil
+		}
+	}
+	//fmt.Printf("Replaced contents: %s\n", replaced)
+	if matches > 0 { // Only Write out on match.
+
+		if slices.Equal(read, replaced) { // Don't write out if there are no updates.
+			c.Info.CheckedFilePaths = append(c.Info.CheckedFilePaths, path)
+			if c.report != nil {
+				c.report.Unchanged = append(c.report.Unchanged, path)
 			}
+			return nil, nil
 		}
-		return nil
+		return &rewrite{path: path, b: replaced, replaces: matches}, nil
 	}
+	return nil, nil
+}
 
-	err = filepath.WalkDir(c.Dist, walk)
-	return err
+// replaceRefs returns b, the content of the file at from, relative to pwd,
+// with references to versioned files replaced with their current version, the
+// number of references, and whether any reference is dangling.  c.refs must
+// be set.
+func replaceRefs(c *Config, from string, b []byte) (replaced []byte, matches int, dangling bool) {
+	last := 0
+	for _, m := range c.refs.FindAllIndex(b, -1) {
+		ref, dummied, ok := currentRef(c, from, string(b[m[0]:m[1]]))
+		if !ok {
+			continue
+		}
+		if dummied {
+			dangling = true
+		}
+		replaced = append(replaced, b[last:m[0]]...)
+		replaced = append(replaced, ref...)
+		last = m[1]
+		matches++
+	}
+	if matches == 0 {
+		return b, 0, false
+	}
+	return append(replaced, b[last:]...), matches, dangling
 }
 
 // Index builds an index of what files The index map, has the key of the version
@@ -239,56 +502,123 @@ func IndexReplace(c *Config) {
 	// Index
 }
 
+// isPreviousVersion reports whether path (relative to pwd) is a versioned file
+// in c.Dist that is not the current version in c.Info.PV, e.g. a previous
+// version retained by c.Retention.
+func isPreviousVersion(c *Config, path string) bool {
+	rel, err := filepath.Rel(c.Dist, path)
+	if err != nil {
+		return false
+	}
+	p := Populated(filepath.ToSlash(rel))
+	v, ok := c.Info.PV[p.BarePath]
+	return ok && p.Version != "" && p.Version != v
+}
+
 // genFileVer generates the pathed fileVer (e.g. e/app~fv=0000.min.js) from the
-// bare relative file name (`e/app.min.js`) and digest (0000...). Input `digest`
-// may be shortened (0000) instead of the whole digest. If digest is empty or
-// too short, version is zeroed.
-func genFileVer(file, digest string, c *Config) (filever string, dummied bool) {
-	if digest == "" || len(digest) < VersionSize {
-		digest = strings.Repeat("0", VersionSize)
+// bare relative file name (`e/app.min.js`) and version (0000...).  The version
+// is used whole, so it may be longer than VersionSize if extended by
+// c.Collision.  If version is empty or too short, version is zeroed.
+func genFileVer(file, version string, c *Config) (filever string, dummied bool) {
+	if len(version) < VersionSize {
+		version = DummyVersion()
 		dummied = true
 	}
 	p := Populated(file)
-	fv := p.Dir + p.Bare + Delim + digest[:VersionSize] + p.Ext
+	fv := p.Dir + p.Bare + Delim + version + p.Ext
 	return fv, dummied
 }
 
 // genFileVerRegex Returns the regex to find the versioned file encapsulated in
-// parentheses, e.g. `(subdir/test_3\~fv=[0-9A-Za-z_-]*.js)`.  Variable `file`
+// parentheses, e.g. `(subdir/test_3~fv=[0-9A-Za-z_-]*\.js)`.  Variable `file`
 // should be the bare relative file path.  E.g. `subdir/test_3.js` or
 // `test_1.js`.
 func genFileVerRegex(file string, c *Config) (regex string) {
 	dir, base := path.PathCut(file)
 	// strings.Cut splits on first instance.  Resulting excludes match.
-	baseWithoutExt, ext, _ := strings.Cut(base, ".")
-	return "(" + regexp.QuoteMeta(dir+baseWithoutExt) + VerAnySizeRegex + "." + ext + ")"
+	baseWithoutExt, ext, found := strings.Cut(base, ".")
+	if found {
+		ext = "." + ext
+	}
+	return "(" + regexp.QuoteMeta(dir+baseWithoutExt) + VerAnySizeRegex + regexp.QuoteMeta(ext) + ")"
 }
 
-// CleanVersionFiles removes any versioned files recursively in the given path.
+// CleanVersionFiles removes any versioned files, including compressed
+// siblings, recursively in the given path.  Files ignored by the path's
+// IgnoreFile are not removed.
 func CleanVersionFiles(path string) error {
+	return CleanVersionFilesContext(context.Background(), path)
+}
+
+// CleanVersionFilesContext is CleanVersionFiles() stopping, with ctx.Err(),
+// when ctx is done.
+func CleanVersionFilesContext(ctx context.Context, path string) error {
+	f, err := newFilter(nil, path)
+	if err != nil {
+		return err
+	}
+	return cleanVersionFiles(ctx, nil, path, f)
+}
+
+// Clean removes any versioned files, including compressed siblings, from
+// c.Dist.  Files skipped by c.Include, c.Exclude, or c.Dist's IgnoreFile are
+// not removed.
+func Clean(c *Config) error {
+	return CleanContext(context.Background(), c)
+}
+
+// CleanContext is Clean() stopping, with ctx.Err(), when ctx is done.
+func CleanContext(ctx context.Context, c *Config) error {
+	f, err := newFilter(c, c.Dist)
+	if err != nil {
+		return err
+	}
+	return cleanVersionFiles(ctx, c, c.Dist, f)
+}
+
+// cleanVersionFiles removes versioned files in dir not skipped by f.  c may be
+// nil.
+func cleanVersionFiles(ctx context.Context, c *Config, dir string, f *filter) error {
 	// Walk walks all files (recursively) in directory.
 	// Variable path is relative to to running location of the program (program root dir).
-	var walk = func(path string, d fs.DirEntry, err error) error {
+	// Failing files don't stop the walk.
+	var errs []error
+	var walk = func(path, _ string, d fs.DirEntry) error {
 		//fmt.Printf("Clean Walk - path: %s; d: %+v\n", path, d)
-		if err != nil {
-			return err
-		}
-		if d.IsDir() {
-			return nil
-		}
-
 		if VerAnySizeRegexC.Match([]byte(path)) {
 			//fmt.Printf("Matched: %s; removing\n", path)
 			err := os.RemoveAll(path)
 			if err != nil {
-				panic(err)
+				errs = append(errs, fileOpErr("remove", path, err))
+				return nil
 			}
+			emit(c, VersionDeleted{Path: path})
 		}
 
 		return nil
 	}
 
-	return filepath.WalkDir(path, walk)
+	err := walkFiles(ctx, dir, f, walk)
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return errors.Join(append(errs, err)...)
+}
+
+// srcVersionedFiles returns the existing versioned files in c.Src not skipped
+// by c.Include, c.Exclude, or c.Src's IgnoreFile.  Returns paths relative to
+// c.Src.
+func srcVersionedFiles(ctx context.Context, c *Config) (fileVers []string, err error) {
+	f, err := newFilter(c, c.Src)
+	if err != nil {
+		return nil, err
+	}
+	return fileVers, walkFiles(ctx, c.Src, f, func(_, rel string, d fs.DirEntry) error {
+		if VerRegexC.MatchString(d.Name()) {
+			fileVers = append(fileVers, filepath.FromSlash(rel))
+		}
+		return nil
+	})
 }
 
 // ExistingVersionedFiles returns all existing versioned files in  `directory`
@@ -369,8 +699,10 @@ func ListFilesInPath(path string) (files []string, err error) {
 
 // FileToFileVerOutputDelete accepts a filepath (versions or dummied), e.g.
 // `subdir/test_3~fv=00000000.js`, copies the file renamed with its correct
-// FileVer to an output directory, and deletes any previous version in the
-// output directory. Returns outFilePath, relative to c.Dist, which itself is
+// (canonical) FileVer to an output directory, and deletes any previous version in the
+// output directory unless c.Retention is set.  If c.Info is set, previous
+// versions are instead collected and deleted by Version(), or by
+// VersionReplace() after Replace(). Returns outFilePath, relative to c.Dist, which itself is
 // relative to `pwd`.  This function ignores subdirectories, and does not
 // re-hash files already in output directory.
 //
@@ -381,117 +713,168 @@ func ListFilesInPath(path string) (files []string, err error) {
 // filePath
 func FileToFileVerOutputDelete(filePath string, c *Config) (outFilePath string, err error) {
 	//fmt.Printf("FileToFileVerOutputDelete filePath: %s src: %s dist: %s \n", filePath, c.Src, c.Dist)
-	dig, _, err := HashFile(c.Src+string(os.PathSeparator)+filePath, HashAlg)
+	in := c.Src + string(os.PathSeparator) + filePath
+	dig, b, err := HashFileCanonical(in, HashAlg)
 	if err != nil {
-		return "", err
+		return "", fileOpErr("hash", in, err)
 	}
 
-	fileVer, dummied := genFileVer(filePath, dig.String(), c)
-	if dummied {
-		return "", fmt.Errorf("Dummied version returned %s for %s\n", fileVer, filePath)
-	}
 	p := Populated(filePath)
 	rPath := strings.Replace(p.Dir, c.Src, "", 1)
 	distRDir := c.Dist + string(os.PathSeparator) + rPath
-	//fmt.Printf("FileVer: %s, rPath: %s, distRDir: %s\n", fileVer, rPath, distRDir)
 
 	if rPath != "" {
 		// fmt.Printf("creating relative dirs: %s", distRDir)
-		os.MkdirAll(distRDir, 0755)
+		err = os.MkdirAll(distRDir, 0755)
 		if err != nil {
-			return "", err
+			return "", fileOpErr("mkdir", distRDir, err)
 		}
 	}
 
 	// Check if the FileVer already exists in output directory, if it does, don't
 	// copy.  Regardless, also check for existing and/or previous versions in
 	// output directory.
-	files, err := ListFilesInPath(distRDir)
+	versions, err := distVersions(c, filePath, distRDir)
+	if err != nil {
+		return "", err
+	}
+	bare := Populated(filepath.ToSlash(filePath)).BarePath
+	v, err := version(c, bare, dig.String(), distRDir, versions)
 	if err != nil {
 		return "", err
 	}
+	fileVer, _ := genFileVer(filePath, v, c)
+	emit(c, FileHashed{Path: in, Version: v})
+	err = lockFile(c, bare, v, len(*b))
+	if err != nil {
+		return "", err
+	}
+	if c.Info != nil {
+		if c.Info.versions == nil {
+			c.Info.versions = map[string]versionOwner{}
+		}
+		c.Info.versions[v] = versionOwner{bare: bare, digest: dig.String()}
+	}
 
-	// Search for FileVer, e.g. `e/app~fv=00000000.min.js.map`. Must
-	// match whole file name since if just matching substring files with
-	// alternative extensions, e.g. `min.js` vs `min.js.map`, will match when they
-	// shouldn't.
-	base := VerAnySizeRegexC.ReplaceAllString(filepath.Base(filePath), "")
-	anyVersionReg := regexp.MustCompile(genFileVerRegex(base, c))
 	matchedExisting := false
+	var old string // Version of the most recent previous version, if any.
+	var oldMod time.Time
 
-	for _, f := range files {
-		if !matchedExisting && f == fileVer { // File is the current version.
+	for _, f := range versions {
+		if !matchedExisting && f == filepath.Base(fileVer) { // File is the current version.
 			matchedExisting = true
 			continue // Continue in case of other errant copies.
 		}
 
-		matched := anyVersionReg.Match([]byte(f))
-		if !matched {
+		if c.report != nil {
+			fi, err := os.Stat(filepath.Join(distRDir, f))
+			if err == nil && (old == "" || fi.ModTime().After(oldMod)) {
+				old, oldMod = Populated(f).Version, fi.ModTime()
+			}
+		}
+		if c.Retention != nil { // Previous versions are removed by Prune().
 			continue
 		}
 
-		// Existing file is a different version than new file.  Delete Existing.
-		del := c.Dist + string(os.PathSeparator) + rPath + string(os.PathSeparator) + f
-		//fmt.Printf("Delete matched: %s file: %s", escapedAnyVersion, del)
-		err := os.Remove(del)
+		// Existing file is a different version than new file.  Delete Existing,
+		// after Replace() if c.Info is set.
+		del := filepath.Join(distRDir, f)
+		if c.Info != nil {
+			c.Info.previous = append(c.Info.previous, del)
+			continue
+		}
+		err := removeVersion(c, del)
 		if err != nil {
 			return "", err
 		}
 		// Continue in case of other errant copies.
 	}
 
+	if c.report != nil {
+		if matchedExisting {
+			old = Populated(fileVer).Version
+		}
+		c.report.Files = append(c.report.Files, FileReport{
+			BarePath:   Populated(filepath.ToSlash(fileVer)).BarePath,
+			Digest:     dig.String(),
+			OldVersion: old,
+			Version:    Populated(fileVer).Version,
+			Size:       len(*b),
+		})
+	}
+
 	if matchedExisting { // Don't re-copy is matched with current FileVer.
 		return fileVer, nil
 	}
 
 	// Copy into output directory.
-	in := c.Src + string(os.PathSeparator) + filePath
 	//fmt.Printf("in: %s", in)
 	input, err := os.ReadFile(in)
 	if err != nil {
-		return "", err
+		return "", fileOpErr("read", in, err)
 	}
 
 	o := c.Dist + string(os.PathSeparator) + fileVer
 	//fmt.Printf("Writing copy to: %s", o)
-	err = os.WriteFile(o, input, 0644)
+	err = writeFile(o, input, 0644)
 	if err != nil {
-		return "", err
+		return "", fileOpErr("write", o, err)
 	}
+	emit(c, FileCopied{From: in, To: o})
 
 	return fileVer, nil
 }
 
-func genSrcReg(c *Config) {
-	if c.SrcReg != nil { // Don't recompile if set.
-		return
+// distVersions returns the versioned files in distRDir, the directory in
+// c.Dist of the src file filePath, that are any version of filePath, including
+// the current version.  Compressed siblings are not returned.  Returns file
+// names.
+func distVersions(c *Config, filePath, distRDir string) (versions []string, err error) {
+	files, err := ListFilesInPath(distRDir)
+	if os.IsNotExist(err) {
+		return nil, nil
 	}
-	if c.UseSAVR {
-		genSAVR(c)
-		c.SrcReg = regexp.MustCompile(c.Info.SAVR)
-		return
+	if err != nil {
+		return nil, err
 	}
 
-	c.SrcReg = regexp.MustCompile(FileVerPathReg)
+	// Search for FileVer, e.g. `e/app~fv=00000000.min.js.map`. Must
+	// match whole file name since if just matching substring files with
+	// alternative extensions, e.g. `min.js` vs `min.js.map`, will match when they
+	// shouldn't.
+	base := VerAnySizeRegexC.ReplaceAllString(filepath.Base(filePath), "")
+	anyVersionReg, err := regexp.Compile("^" + genFileVerRegex(base, c) + "$")
+	if err != nil {
+		return nil, &ConfigError{Field: "VerAnySizeRegex", Msg: "is not a valid regex", Err: err}
+	}
+	for _, f := range files {
+		if isCompressedSibling(f) { // Removed with their versioned file.
+			continue
+		}
+		if anyVersionReg.MatchString(f) {
+			versions = append(versions, f)
+		}
+	}
+	return versions, nil
 }
 
-// Generate SAVR.  e.g.
-// (subdir/test_3\\~fv=[0-9A-Za-z_-]*.js)|(test_1\\~fv=[0-9A-Za-z_-]*.js)
-func genSAVR(c *Config) {
-	c.Info.SAVR = ""
-	bookEnd := false
-	for _, k := range c.Info.VersionedFiles {
-		nv := VerAnySizeRegexC.ReplaceAllString(k, "") // get bare file name without version.
-		nv = strings.ReplaceAll(nv, c.Dist, "")        // Remove dist (imports are relative to dist).
-
-		if bookEnd { // Fencepost
-			c.Info.SAVR += "|"
-		} else {
-			bookEnd = true
+// genSrcReg sets c.refs to c.SrcReg, if set, otherwise the literal matcher of
+// the versioned files if c.UseSAVR, otherwise c.SrcReg compiled from
+// FileVerPathReg.  The literal matcher is regenerated on every call since it
+// depends on c.Info.VersionedFiles.
+func genSrcReg(c *Config) (err error) {
+	if c.SrcReg == nil && c.UseSAVR {
+		c.refs = newLiteralMatcher(c)
+		return nil
+	}
+	if c.SrcReg == nil {
+		c.SrcReg, err = regexp.Compile(FileVerPathReg)
+		if err != nil {
+			return &ConfigError{Field: "FileVerPathReg", Msg: "is not a valid regex", Err: err}
 		}
-
-		c.Info.SAVR += genFileVerRegex(nv, c)
 	}
+	c.refs = c.SrcReg
+	return nil
 }
 
 // HashFile accepts a path, a hashing algorithm, return digest and pointer to file.
@@ -507,3 +890,33 @@ func HashFile(path string, alg coze.HshAlg) (digest coze.B64, file *[]byte, err
 	//fmt.Printf("FileDigest: %X", d)
 	return coze.B64(d), &fileBytes, nil
 }
+
+// writeFile writes b to the file name by writing a temporary file in the same
+// directory and renaming it over name, so that name is never left partially
+// written, e.g. on cancel.  An existing file's permissions are kept, otherwise
+// perm is used.
+func writeFile(name string, b []byte, perm fs.FileMode) (err error) {
+	if fi, err := os.Stat(name); err == nil {
+		perm = fi.Mode().Perm()
+	}
+	f, err := os.CreateTemp(filepath.Dir(name), "."+filepath.Base(name)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			os.Remove(f.Name())
+		}
+	}()
+	_, err = f.Write(b)
+	if err == nil {
+		err = f.Chmod(perm)
+	}
+	if err1 := f.Close(); err == nil {
+		err = err1
+	}
+	if err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), name)
+}
@@ -19,7 +19,46 @@ var dummyNoSrc = "test/dummy_no/src"
 var dummyNoDist = "test/dummy_no/dist"
 var watchSrc = "test/watch/src"
 var watchDist = "test/watch/dist"
-var cleanDist = "test/clean" // For ExampleCleanVersionFiles. Uses dummySrc as src.
+var currentDist = "test/current"     // For TestVersion_current.  Uses dummySrc as src.
+var cleanDist = "test/clean"         // For ExampleCleanVersionFiles. Uses dummySrc as src.
+var unversionOut = "test/unversion"  // For ExampleUnversion.  Uses dummyDist as dist.
+var retentionDist = "test/retention" // For ExamplePrune.  Uses dummySrc as src.
+var historySrc = "test/history/src"  // For ExampleRollback.  Generated by the example.
+var historyDist = "test/history/dist"
+var rollbackErrorsDist = "test/rollback_errors" // For TestRollback_errors.  Uses dummySrc as src.
+var watchFSSrc = "test/watch_fs/src"            // For TestWatch.  Generated by the test.
+var watchFSDist = "test/watch_fs/dist"
+var transformSrc = "test/transform/src" // For ExampleTransform.  Generated by the example.
+var transformDist = "test/transform/dist"
+var compressSrc = "test/compress/src" // For ExampleWriteCompressed.  Generated by the example.
+var compressDist = "test/compress/dist"
+var compressUpdatedDist = "test/compress_updated" // For TestWriteCompressed_updated.  Uses dummySrc as src.
+var binarySrc = "test/binary/src"                 // For ExampleReplace_binary.  Generated by the example.
+var binaryDist = "test/binary/dist"
+var ignoreSrc = "test/ignore/src" // For ExampleVersionReplace_ignore.  Generated by the example.
+var ignoreDist = "test/ignore/dist"
+var danglingDist = "test/dangling"               // For ExampleReplace_dangling.  Uses dummySrc as src.
+var observeDist = "test/observe"                 // For ExampleObserver.  Uses dummySrc as src.
+var contextDist = "test/context"                 // For ExampleVersionReplaceContext.  Uses dummySrc as src.
+var contextCancelSrc = "test/context_cancel/src" // For TestVersionReplaceContext_cancel.  Generated by the test.
+var contextCancelDist = "test/context_cancel/dist"
+var reportDist = "test/report"                    // For ExampleRun.  Uses dummySrc as src.
+var checkDist = "test/check"                      // For ExampleCheck.  Uses dummySrc as src.
+var lockDist = "test/lock"                        // For ExampleLock.  Uses dummySrc as src.  The lock file is test/lock.json.
+var collisionDist = "test/collision"              // For ExampleCollisionPolicy.  Uses dummySrc as src.
+var collisionStableDist = "test/collision_stable" // For TestCollisionExtend_stable.  Uses dummySrc as src.  The lock file is test/collision_stable.json.
+var relativeSrc = "test/relative/src"             // For ExampleReplace_relative.  Generated by the example.
+var relativeDist = "test/relative/dist"
+var prefixSrc = "test/prefix/src" // For ExampleURLPrefix.  Generated by the example.
+var prefixDist = "test/prefix/dist"
+var literalSrc = "test/literal/src" // For TestLiteralMatcher_resolve.  Generated by the test.
+var literalDist = "test/literal/dist"
+var handlerDist = "test/handler"                             // For TestFileHandler.
+var srcRegDist = "test/src_reg"                              // For TestSrcReg.  Uses dummySrc as src.
+var checkCollisionDist = "test/check_collision"              // For TestCheck_collisionExtend.  Uses dummySrc as src.
+var defaultLoggerDist = "test/default_logger"                // For TestDefaultLogger.  Uses dummySrc as src.
+var unversionRetentionDist = "test/unversion_retention/dist" // For TestUnversion_retention.  Uses dummySrc as src.
+var unversionRetentionOut = "test/unversion_retention/out"
 
 func init() {
 	clean()
@@ -34,19 +73,26 @@ func ExampleFileVerPathReg() {
 `
 
 	c := &Config{Src: dummySrc, Dist: dummyDist}
-	genSrcReg(c)
+	err := genSrcReg(c)
+	if err != nil {
+		panic(err)
+	}
 
 	matches := c.SrcReg.FindAllString(ts, -1)
 	fmt.Println(matches)
 	// Output:
-	//[test_1~fv=00000000.js /test_2~fv=00000000.js /subdir/test_3~fv=00000000.js /subdir/test_4~fv=00000000.js]
+	//[test_1~fv=00000000.js ./test_2~fv=00000000.js ./subdir/test_3~fv=00000000.js ./subdir/test_4~fv=00000000.js]
 }
 
 // Example VersionReplace with mid version with "dummy" input files.
 // go test -run '^ExampleVersionReplace$'
 func ExampleVersionReplace() {
+	err := CleanVersionFiles(dummyDist) // Other examples also version into dummyDist.
+	if err != nil {
+		panic(err)
+	}
 	c := &Config{Src: dummySrc, Dist: dummyDist}
-	err := VersionReplace(c)
+	err = VersionReplace(c)
 	if err != nil {
 		panic(err)
 	}
@@ -54,7 +100,6 @@ func ExampleVersionReplace() {
 	PrintFile(dummyDist + "/" + c.Info.VersionedFiles[0])
 
 	// Output:
-	// ***WARNING*** Digest empty or too small for test_3.js
 	// {
 	// 	"Src": "test/dummy/src",
 	// 	"SrcFiles": [
@@ -63,9 +108,26 @@ func ExampleVersionReplace() {
 	// 		"test_1~fv=00000000.js",
 	// 		"test_2~fv=00000000.js"
 	// 	],
-	// 	"SrcReg": {},
+	// 	"SrcReg": "(?:(?:[A-Za-z][0-9A-Za-z+.\\-]*://[0-9A-Za-z.\\-]+(?::[0-9]+)?)?(?:(?:[0-9A-Za-z\\-_~@!$+]|%[0-9A-Fa-f]{2}|[^\\x00-\\x7F\\p{Z}\\p{Pi}\\p{Pf}])|[./])*/)?(?:[0-9A-Za-z\\-_~@!$+]|%[0-9A-Fa-f]{2}|[^\\x00-\\x7F\\p{Z}\\p{Pi}\\p{Pf}])*~fv=[0-9A-Za-z_\\-]*(?:\\.[0-9A-Za-z_\\-]+)*(?:\\?[^\\s\"'()\\x3c\\x3e\\x60\\\\#]*)?(?:#[^\\s\"'()\\x3c\\x3e\\x60\\\\#]*)?",
 	// 	"Dist": "test/dummy/dist",
 	// 	"UseSAVR": false,
+	// 	"Manifest": false,
+	// 	"History": false,
+	// 	"Retention": null,
+	// 	"Debounce": 0,
+	// 	"WatchDist": false,
+	// 	"Transformers": null,
+	// 	"Compress": null,
+	// 	"Include": null,
+	// 	"Exclude": null,
+	// 	"TextExts": null,
+	// 	"Dangling": 0,
+	// 	"URLPrefixes": null,
+	// 	"Lock": 0,
+	// 	"LockPath": "",
+	// 	"Collision": 0,
+	// 	"Logger": null,
+	// 	"Observer": null,
 	// 	"Info": {
 	// 		"PV": {
 	// 			"subdir/test_3.js": "_X83uO__",
@@ -88,7 +150,9 @@ func ExampleVersionReplace() {
 	// 			"test/dummy/dist/subdir/test_4~fv=GJIrg6k1.js",
 	// 			"test/dummy/dist/test_1~fv=vPCb4GVO.js",
 	// 			"test/dummy/dist/test_2~fv=BOl7h9TM.js"
-	// 		]
+	// 		],
+	// 		"DanglingRefs": null,
+	// 		"SkippedFilePaths": null
 	// 	}
 	// }
 	// File test/dummy/dist/subdir/test_3~fv=_X83uO__.js:
@@ -134,8 +198,6 @@ func Example_watchVersionAndReplace() {
 	// Flag `daemon` set to false.  Running commands in config and exiting.
 
 	// Replace Config  &{Src:test/watch/src SrcFiles:[subdir/test_3~fv=00000000.js subdir/test_4~fv=00000000.js test_1~fv=00000000.min.js test_2~fv=00000000.js] SrcReg:<nil> Dist:test/watch/dist UseSAVR:false Info:0xc0001dc5b0} Info: &{PV:map[subdir/test_3.js:gia0-_Z_ subdir/test_4.js:da1EKBXZ test_1.min.js:1sTEzePc test_2.js:qBbNrrTr] SAVR: VersionedFiles:[subdir/test_3~fv=gia0-_Z_.js subdir/test_4~fv=da1EKBXZ.js test_1~fv=1sTEzePc.min.js test_2~fv=qBbNrrTr.js] Index:map[] TotalSourceReplaces:0 UpdatedFilePaths:[] CurrentPath: CurrentMatches:0}
-	// ***WARNING*** Digest empty for test_3.js
-	// ***WARNING*** Digest empty for test_1.js
 	// {
 	// 	"Src": "test/watch/src",
 	// 	"SrcFiles": [
@@ -147,6 +209,23 @@ func Example_watchVersionAndReplace() {
 	// 	"SrcReg": {},
 	// 	"Dist": "test/watch/dist",
 	// 	"UseSAVR": false,
+	// 	"Manifest": false,
+	// 	"History": false,
+	// 	"Retention": null,
+	// 	"Debounce": 0,
+	// 	"WatchDist": false,
+	// 	"Transformers": null,
+	// 	"Compress": null,
+	// 	"Include": null,
+	// 	"Exclude": null,
+	// 	"TextExts": null,
+	// 	"Dangling": 0,
+	// 	"URLPrefixes": null,
+	// 	"Lock": 0,
+	// 	"LockPath": "",
+	// 	"Collision": 0,
+	// 	"Logger": null,
+	// 	"Observer": null,
 	// 	"Info": {
 	// 		"PV": {
 	// 			"subdir/test_3.js": "gia0-_Z_",
@@ -169,7 +248,9 @@ func Example_watchVersionAndReplace() {
 	// 			"test/watch/dist/test_1.min.js.map",
 	// 			"test/watch/dist/test_1~fv=1sTEzePc.min.js",
 	// 			"test/watch/dist/test_2~fv=qBbNrrTr.js"
-	// 		]
+	// 		],
+	// 		"DanglingRefs": null,
+	// 		"SkippedFilePaths": null
 	// 	}
 	// }
 	// File test/watch/dist/subdir/test_3~fv=gia0-_Z_.js:
@@ -263,11 +344,42 @@ func ExampleCleanVersionFiles() {
 	fmt.Println(c.Info.VersionedFiles)
 	fmt.Println(f)
 	// Output:
-	// ***WARNING*** Digest empty or too small for test_3.js
 	// [subdir/test_3~fv=_X83uO__.js subdir/test_4~fv=GJIrg6k1.js test_1~fv=vPCb4GVO.js test_2~fv=BOl7h9TM.js]
 	// [not_versioned_example.txt]
 }
 
+// TestVersion_current tests that current versions, including in
+// subdirectories, are neither deleted nor copied again.
+func TestVersion_current(t *testing.T) {
+	err := os.RemoveAll(currentDist)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.MkdirAll(currentDist, 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = Version(&Config{Src: dummySrc, Dist: currentDist})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var events []Event
+	c := &Config{Src: dummySrc, Dist: currentDist, Observer: ObserverFunc(func(e Event) {
+		switch e.(type) {
+		case FileCopied, VersionDeleted:
+			events = append(events, e)
+		}
+	})}
+	err = Version(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 0 {
+		t.Errorf("current versions copied or deleted: %v", events)
+	}
+}
+
 func Test_clean(t *testing.T) {
 	clean()
 }
@@ -279,6 +391,8 @@ func clean() {
 		dummyNoDist,
 		watchDist,
 		cleanDist,
+		unversionOut,
+		retentionDist,
 	}
 
 	for _, v := range c {
@@ -297,6 +411,8 @@ func nukeAndRebuildTestDirs() {
 		dummyNoDist,
 		watchDist,
 		cleanDist,
+		unversionOut,
+		retentionDist,
 	}
 
 	for _, v := range c {
@@ -0,0 +1,84 @@
+package filever
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"io"
+	"strings"
+	"unicode"
+)
+
+// GenerateGo writes Go source for package pkg with a typed asset table for the
+// versioned files in m, for use with `go generate` and `embed.FS`.  The source
+// has a constant per bare path whose value is the pathed FileVer relative to
+// `dist`, and the map `Assets` of bare path to Asset.  For example, bare path
+// `e/app.min.js` generates:
+//
+//	const EAppMinJs = "e/app~fv=4mIbJJPq.min.js"
+//
+// Constant names are generated from the bare path.  GenerateGo errors if two
+// bare paths generate the same name, or if a bare path generates `Asset` or
+// `Assets`, e.g. bare path `asset`.
+func GenerateGo(w io.Writer, pkg string, m *Manifest) (err error) {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "// Code generated by filever; DO NOT EDIT.\n\npackage %s\n\n", pkg)
+	b.WriteString(`// Asset is a versioned file.
+//
+//	Path      - Pathed FileVer relative to dist, e.g. "e/app~fv=4mIbJJPq.min.js".
+//	Version   - Version, e.g. "4mIbJJPq".
+//	Integrity - Subresource Integrity.
+type Asset struct {
+	Path      string
+	Version   string
+	Integrity string
+}
+
+`)
+
+	bares := sortedKeys(m.PV)
+	names := map[string]string{"Asset": "type Asset", "Assets": "var Assets"} // name:bare path
+	b.WriteString("// Pathed FileVers relative to dist.\nconst (\n")
+	for _, bare := range bares {
+		n := goIdent(bare)
+		if other, ok := names[n]; ok {
+			return &CollisionError{Name: "constant " + n, Paths: []string{other, bare}}
+		}
+		names[n] = bare
+		fv, _ := m.FileVer(bare)
+		fmt.Fprintf(&b, "%s = %q // %s\n", n, fv, bare)
+	}
+	b.WriteString(")\n\n")
+
+	b.WriteString("// Assets is bare path:Asset.\nvar Assets = map[string]Asset{\n")
+	for _, bare := range bares {
+		fmt.Fprintf(&b, "%q: {Path: %s, Version: %q, Integrity: %q},\n", bare, goIdent(bare), m.PV[bare], m.Integrity[bare])
+	}
+	b.WriteString("}\n")
+
+	src, err := format.Source(b.Bytes())
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(src)
+	return err
+}
+
+// goIdent returns an exported Go identifier for the bare path, e.g.
+// `e/app.min.js` is `EAppMinJs`.
+func goIdent(barePath string) string {
+	var sb strings.Builder
+	parts := strings.FieldsFunc(barePath, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for _, p := range parts {
+		r := []rune(p)
+		r[0] = unicode.ToUpper(r[0])
+		sb.WriteString(string(r))
+	}
+	s := sb.String()
+	if s == "" || !unicode.IsUpper([]rune(s)[0]) {
+		s = "F" + s
+	}
+	return s
+}
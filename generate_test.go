@@ -0,0 +1,70 @@
+package filever
+
+import (
+	"errors"
+	"io"
+	"os"
+	"testing"
+)
+
+func ExampleGenerateGo() {
+	c := &Config{Src: dummySrc, Dist: dummyDist}
+	err := VersionReplace(c)
+	if err != nil {
+		panic(err)
+	}
+	m, err := NewManifest(c)
+	if err != nil {
+		panic(err)
+	}
+
+	err = GenerateGo(os.Stdout, "assets", m)
+	if err != nil {
+		panic(err)
+	}
+
+	// Output:
+	// // Code generated by filever; DO NOT EDIT.
+	//
+	// package assets
+	//
+	// // Asset is a versioned file.
+	// //
+	// //	Path      - Pathed FileVer relative to dist, e.g. "e/app~fv=4mIbJJPq.min.js".
+	// //	Version   - Version, e.g. "4mIbJJPq".
+	// //	Integrity - Subresource Integrity.
+	// type Asset struct {
+	// 	Path      string
+	// 	Version   string
+	// 	Integrity string
+	// }
+	//
+	// // Pathed FileVers relative to dist.
+	// const (
+	// 	SubdirTest3Js = "subdir/test_3~fv=_X83uO__.js" // subdir/test_3.js
+	// 	SubdirTest4Js = "subdir/test_4~fv=GJIrg6k1.js" // subdir/test_4.js
+	// 	Test1Js       = "test_1~fv=vPCb4GVO.js"        // test_1.js
+	// 	Test2Js       = "test_2~fv=BOl7h9TM.js"        // test_2.js
+	// )
+	//
+	// // Assets is bare path:Asset.
+	// var Assets = map[string]Asset{
+	// 	"subdir/test_3.js": {Path: SubdirTest3Js, Version: "_X83uO__", Integrity: "sha384-FT/Kt0RJ+jExgj6Mv9UfohO9oAd1w2f+N0Fj3bSZ0nVcq9YLbRdkyDWMP6InVaqd"},
+	// 	"subdir/test_4.js": {Path: SubdirTest4Js, Version: "GJIrg6k1", Integrity: "sha384-igEySyIFQgN0k0u3Bo3o3Dsj1J7kvOizA2otbjtv5k76i+l40q9lCZs6wzVdi3lN"},
+	// 	"test_1.js":        {Path: Test1Js, Version: "vPCb4GVO", Integrity: "sha384-k0eH+m8+DbS5n1V8jOrgU0rFBGVPOar29o1dhVZazx+DVE2DHC4C09bjn9/W/0RE"},
+	// 	"test_2.js":        {Path: Test2Js, Version: "BOl7h9TM", Integrity: "sha384-HTCyJ9cxJN6twP9QdT2B1gEfOgT8+TRI1Z1LxHf3EUtL7PkVqNcXXDZQeEeZvI6+"},
+	// }
+}
+
+// TestGenerateGo_reserved tests that bare paths generating the names of the
+// generated type and map collide.
+func TestGenerateGo_reserved(t *testing.T) {
+	for _, bare := range []string{"asset", "assets", "Asset"} {
+		m := &Manifest{PV: map[string]string{bare: "4mIbJJPq"}}
+		err := GenerateGo(io.Discard, "assets", m)
+		var ce *CollisionError
+		if !errors.As(err, &ce) {
+			t.Errorf("GenerateGo(%s) = %v, want *CollisionError", bare, err)
+		}
+	}
+}
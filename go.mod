@@ -1,6 +1,6 @@
 module github.com/cyphrme/filever
 
-go 1.19
+go 1.21
 
 // Go Mod and Go Work Zami Tutorial
 // Go work/go mod/go has a bug: https://github.com/golang/go/issues/54264
@@ -43,15 +43,18 @@ replace (
 )
 
 require (
+	github.com/andybalholm/brotli v1.0.6
+	github.com/bmatcuk/doublestar/v4 v4.6.1
 	github.com/cyphrme/coze v0.0.5
 	github.com/cyphrme/path v0.0.0-00010101000000-000000000000
 	github.com/cyphrme/watchmod v0.0.0-00010101000000-000000000000
+	github.com/evanw/esbuild v0.17.19
+	github.com/fsnotify/fsnotify v1.4.9
 	golang.org/x/exp v0.0.0-20220722155223-a9213eeb770e
 )
 
 require (
 	github.com/DisposaBoy/JsonConfigReader v0.0.0-20201129172854-99cf318d67e7 // indirect
-	github.com/fsnotify/fsnotify v1.4.9 // indirect
 	golang.org/x/crypto v0.8.0 // indirect
 	golang.org/x/sys v0.7.0 // indirect
 )
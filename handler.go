@@ -0,0 +1,156 @@
+package filever
+
+import (
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Cache-Control header values used by FileHandler.
+const (
+	// CacheImmutable is used for versioned files, which never change.
+	CacheImmutable = "public, max-age=31536000, immutable"
+	// CacheRevalidate is used for non-versioned files and fallbacks.
+	CacheRevalidate = "no-cache"
+)
+
+// Fallback is the FileHandler behavior for requests of a bare path (e.g.
+// `/app.min.js`) or a version that no longer exists (stale) when the manifest
+// has the current version.
+type Fallback int
+
+const (
+	// FallbackNone responds not found.
+	FallbackNone Fallback = iota
+	// FallbackRedirect redirects (302) to the current FileVer.
+	FallbackRedirect
+	// FallbackServe serves the current FileVer with revalidation headers.
+	FallbackServe
+)
+
+// FileHandler is a http.Handler serving files in Dist.  Versioned files are
+// served with immutable caching.  Non-versioned files and dummy versioned
+// files, e.g. `app~fv=00000000.js`, are served with revalidation headers.
+// ETags are the file's digest.  MetaDir and IgnoreFiles are not served.
+//
+//	Dist     - Directory served.
+//	Manifest - Current versions.  Used for fallbacks.  May be nil.
+//	Fallback - Behavior for bare paths and stale versions.  See Fallback.
+type FileHandler struct {
+	Dist     string
+	Manifest *Manifest
+	Fallback Fallback
+
+	etags sync.Map // path:etag
+}
+
+// etag is a cached ETag for a file.
+type etag struct {
+	modTime time.Time
+	size    int64
+	tag     string
+}
+
+// Handler returns a FileHandler serving dist.  Manifest may be nil.  Fallback
+// defaults to FallbackNone and may be set on the returned FileHandler.
+func Handler(dist string, m *Manifest) *FileHandler {
+	return &FileHandler{Dist: dist, Manifest: m}
+}
+
+// ServeHTTP implements http.Handler.
+func (h *FileHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	rel := strings.TrimPrefix(path.Clean("/"+r.URL.Path), "/")
+	if rel == MetaDir || strings.HasPrefix(rel, MetaDir+"/") || path.Base(rel) == IgnoreFile {
+		http.NotFound(w, r)
+		return
+	}
+	if rel == "" || strings.HasSuffix(r.URL.Path, "/") {
+		rel = path.Join(rel, "index.html")
+	}
+	p := Populated(rel)
+
+	if h.serveFile(w, r, rel, p.Version != "" && p.Version != DummyVersion()) {
+		return
+	}
+
+	// Requested file doesn't exist.  Bare path or stale version.
+	if h.Manifest == nil || h.Fallback == FallbackNone {
+		http.NotFound(w, r)
+		return
+	}
+	fv, ok := h.Manifest.FileVer(p.BarePath)
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	if h.Fallback == FallbackRedirect {
+		// Relative to the request, so that it works with http.StripPrefix.
+		loc := path.Base(fv)
+		if r.URL.RawQuery != "" {
+			loc += "?" + r.URL.RawQuery
+		}
+		w.Header().Set("Cache-Control", CacheRevalidate)
+		w.Header().Set("Location", loc)
+		w.WriteHeader(http.StatusFound)
+		return
+	}
+	if !h.serveFile(w, r, fv, false) {
+		http.NotFound(w, r)
+	}
+}
+
+// serveFile serves the file at rel, relative to Dist.  Returns false, without
+// writing, if the file does not exist or is a directory.
+func (h *FileHandler) serveFile(w http.ResponseWriter, r *http.Request, rel string, immutable bool) bool {
+	f, err := os.Open(filepath.Join(h.Dist, filepath.FromSlash(rel)))
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+	fi, err := f.Stat()
+	if err != nil || fi.IsDir() {
+		return false
+	}
+
+	tag, err := h.etag(f.Name(), fi)
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return true
+	}
+	w.Header().Set("ETag", tag)
+	if immutable {
+		w.Header().Set("Cache-Control", CacheImmutable)
+	} else {
+		w.Header().Set("Cache-Control", CacheRevalidate)
+	}
+	http.ServeContent(w, r, fi.Name(), fi.ModTime(), f)
+	return true
+}
+
+// etag returns the ETag, the quoted digest, of the file at name.  ETags are
+// cached until the file's modification time or size changes.
+func (h *FileHandler) etag(name string, fi os.FileInfo) (string, error) {
+	if e, ok := h.etags.Load(name); ok {
+		e := e.(etag)
+		if e.modTime.Equal(fi.ModTime()) && e.size == fi.Size() {
+			return e.tag, nil
+		}
+	}
+	d, _, err := HashFile(name, HashAlg)
+	if err != nil {
+		return "", err
+	}
+	tag := `"` + d.String() + `"`
+	h.etags.Store(name, etag{fi.ModTime(), fi.Size(), tag})
+	return tag, nil
+}
@@ -0,0 +1,100 @@
+package filever
+
+import (
+	"fmt"
+	"net/http/httptest"
+	"os"
+	"testing"
+)
+
+// ExampleHandler demonstrates serving dist with a manifest.
+func ExampleHandler() {
+	c := &Config{Src: dummySrc, Dist: dummyDist}
+	err := VersionReplace(c)
+	if err != nil {
+		panic(err)
+	}
+	m, err := NewManifest(c)
+	if err != nil {
+		panic(err)
+	}
+	h := Handler(dummyDist, m)
+	h.Fallback = FallbackRedirect
+
+	for _, u := range []string{
+		"/test_1~fv=vPCb4GVO.js",        // Current version.
+		"/not_versioned_example.txt",    // Not versioned.
+		"/test_1.js?a=b",                // Bare path.
+		"/subdir/test_3~fv=AAAAAAAA.js", // Stale version.
+		"/missing.js",
+		"/.filever/manifest.json",
+	} {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest("GET", u, nil))
+		out := []any{u, w.Code}
+		for _, k := range []string{"Cache-Control", "ETag", "Location"} {
+			if v := w.Header().Get(k); v != "" {
+				out = append(out, v)
+			}
+		}
+		fmt.Println(out...)
+	}
+
+	// Revalidation
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("GET", "/not_versioned_example.txt", nil)
+	r.Header.Set("If-None-Match", `"xhRvTVyDkv1q84RZyym0cbkZn3X9EucxmgRCIxUthgE"`)
+	h.ServeHTTP(w, r)
+	fmt.Println(w.Code)
+
+	h.Fallback = FallbackServe
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", "/test_2.js", nil))
+	fmt.Println(w.Code, w.Header().Get("Cache-Control"), w.Body.Len())
+
+	// Output:
+	// /test_1~fv=vPCb4GVO.js 200 public, max-age=31536000, immutable "Bz9Isf5p1E5LoheYGk5jBY1DK0qVN4fcGSkHUJSnVYE"
+	// /not_versioned_example.txt 200 no-cache "xhRvTVyDkv1q84RZyym0cbkZn3X9EucxmgRCIxUthgE"
+	// /test_1.js?a=b 302 no-cache test_1~fv=vPCb4GVO.js?a=b
+	// /subdir/test_3~fv=AAAAAAAA.js 302 no-cache test_3~fv=_X83uO__.js
+	// /missing.js 404
+	// /.filever/manifest.json 404
+	// 304
+	// 200 no-cache 304
+}
+
+// TestFileHandler tests that IgnoreFiles aren't served and that dummy
+// versioned files aren't cached as immutable.
+func TestFileHandler(t *testing.T) {
+	err := os.RemoveAll(handlerDist)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.MkdirAll(handlerDist+"/subdir", 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{IgnoreFile, "subdir/" + IgnoreFile, "app~fv=00000000.js"} {
+		err = os.WriteFile(handlerDist+"/"+name, []byte("x\n"), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	h := Handler(handlerDist, nil)
+	for _, tc := range []struct {
+		url   string
+		code  int
+		cache string
+	}{
+		{"/" + IgnoreFile, 404, ""},
+		{"/subdir/" + IgnoreFile, 404, ""},
+		{"/app~fv=00000000.js", 200, CacheRevalidate},
+	} {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest("GET", tc.url, nil))
+		if w.Code != tc.code || w.Header().Get("Cache-Control") != tc.cache {
+			t.Errorf("%s: %d %q, want %d %q", tc.url, w.Code, w.Header().Get("Cache-Control"), tc.code, tc.cache)
+		}
+	}
+}
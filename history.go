@@ -0,0 +1,177 @@
+package filever
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/cyphrme/coze"
+)
+
+// HistoryFile is the name of the release history ledger in MetaDir.  The
+// ledger is JSON lines, one Release per line, oldest first.
+var HistoryFile = "history.jsonl"
+
+// Release is an entry in the history ledger.
+//
+//	ID           - Release ID.  The release digest truncated to VersionSize.
+//	Created      - Time of release.
+//	Digest       - Release digest.  See ReleaseDigest().
+//	PV           - Path:Version of the release, the same as Info.PV.
+//	UpdatedFiles - Files updated by Replace(), the same as Info.UpdatedFilePaths.
+//	Rollback     - If the release is a rollback, the ID of the release rolled back to.
+type Release struct {
+	ID           string
+	Created      time.Time
+	Digest       string
+	PV           map[string]string
+	UpdatedFiles []string
+	Rollback     string `json:",omitempty"`
+}
+
+// ReleaseDigest returns the digest of the whole release, which is the digest of
+// the sorted lines `<bare path>:<version>\n` of pv.
+func ReleaseDigest(pv map[string]string) (digest coze.B64, err error) {
+	var sb strings.Builder
+	for _, k := range sortedKeys(pv) {
+		sb.WriteString(k + ":" + pv[k] + "\n")
+	}
+	d, err := coze.Hash(HashAlg, []byte(sb.String()))
+	if err != nil {
+		return nil, err
+	}
+	return coze.B64(d), nil
+}
+
+// AppendHistory appends a release for c.Info to the history ledger in c.Dist.
+// rollback is the ID of the release rolled back to, if any.
+func AppendHistory(c *Config, rollback string) (r *Release, err error) {
+	if c.Info == nil {
+		return nil, &ConfigError{Field: "c.Info", Msg: "must be set"}
+	}
+	d, err := ReleaseDigest(c.Info.PV)
+	if err != nil {
+		return nil, err
+	}
+	r = &Release{
+		ID:           d.String()[:VersionSize],
+		Created:      time.Now().UTC(),
+		Digest:       d.String(),
+		PV:           c.Info.PV,
+		UpdatedFiles: c.Info.UpdatedFilePaths,
+		Rollback:     rollback,
+	}
+	b, err := json.Marshal(r)
+	if err != nil {
+		return nil, err
+	}
+
+	err = os.MkdirAll(filepath.Join(c.Dist, MetaDir), 0755)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(filepath.Join(c.Dist, MetaDir, HistoryFile), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, err
+	}
+	_, err = f.Write(append(b, '\n'))
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return r, f.Close()
+}
+
+// ReadHistory returns the releases in the history ledger of `dist`, oldest
+// first.
+func ReadHistory(dist string) (releases []*Release, err error) {
+	f, err := os.Open(filepath.Join(dist, MetaDir, HistoryFile))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	s := bufio.NewScanner(f)
+	s.Buffer(nil, 64*1024*1024)
+	for s.Scan() {
+		if len(s.Bytes()) == 0 {
+			continue
+		}
+		r := new(Release)
+		err = json.Unmarshal(s.Bytes(), r)
+		if err != nil {
+			return nil, err
+		}
+		releases = append(releases, r)
+	}
+	return releases, s.Err()
+}
+
+// Rollback restores c.Dist to a previous release without rebuilding.
+// releaseID may be a prefix of the release ID.  If multiple releases match,
+// the most recent is used.  All versioned files of the release must still
+// exist in c.Dist, i.e. they must have been retained (see Config.Retention).
+// Returns a *ConfigError if no release matches, or a *ConfigError wrapping a
+// *FileOpError per missing versioned file.
+//
+// Rollback sets c.Info.PV to the release's PV, runs Replace() to restore
+// references in c.Dist, writes compressed siblings if c.Compress, writes the
+// manifest if c.Manifest, and appends the rollback to the history ledger.
+func Rollback(c *Config, releaseID string) (err error) {
+	releases, err := ReadHistory(c.Dist)
+	if err != nil {
+		return err
+	}
+	var r *Release
+	for i := len(releases) - 1; i >= 0; i-- {
+		if releaseID != "" && strings.HasPrefix(releases[i].ID, releaseID) {
+			r = releases[i]
+			break
+		}
+	}
+	if r == nil {
+		return &ConfigError{Field: "releaseID", Msg: fmt.Sprintf("%q not found in history", releaseID)}
+	}
+
+	c.Info = new(Info)
+	c.Info.PV = r.PV
+	var missing []error
+	for _, bare := range sortedKeys(r.PV) {
+		fv, _ := genFileVer(bare, r.PV[bare], c)
+		name := filepath.Join(c.Dist, filepath.FromSlash(fv))
+		_, err := os.Stat(name)
+		if err != nil {
+			missing = append(missing, fileOpErr("stat", name, err))
+			continue
+		}
+		c.Info.VersionedFiles = append(c.Info.VersionedFiles, fv)
+	}
+	if len(missing) > 0 {
+		return &ConfigError{Field: "releaseID", Msg: fmt.Sprintf("%s versioned files not retained in %s", r.ID, c.Dist), Err: errors.Join(missing...)}
+	}
+
+	err = Replace(c)
+	if err != nil {
+		return err
+	}
+	_, err = WriteCompressed(c)
+	if err != nil {
+		return err
+	}
+	if c.Manifest {
+		err = WriteManifest(c)
+		if err != nil {
+			return err
+		}
+	}
+	_, err = AppendHistory(c, r.ID)
+	return err
+}
@@ -0,0 +1,117 @@
+package filever
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeHistoryRelease writes the src file `app~fv=00000000.js` with content
+// and runs VersionReplace.
+func writeHistoryRelease(c *Config, content string) {
+	err := os.WriteFile(historySrc+"/app~fv=00000000.js", []byte(content), 0644)
+	if err != nil {
+		panic(err)
+	}
+	err = VersionReplace(c)
+	if err != nil {
+		panic(err)
+	}
+}
+
+// ExampleRollback demonstrates rolling back dist to a previous release.
+func ExampleRollback() {
+	for _, d := range []string{historySrc, historyDist} {
+		err := os.RemoveAll(d)
+		if err != nil {
+			panic(err)
+		}
+		err = os.MkdirAll(d, 0755)
+		if err != nil {
+			panic(err)
+		}
+	}
+	err := os.WriteFile(historyDist+"/index.html", []byte(`<script src="app~fv=00000000.js"></script>`), 0644)
+	if err != nil {
+		panic(err)
+	}
+
+	c := &Config{Src: historySrc, Dist: historyDist, History: true, Retention: &Retention{KeepLast: 2}}
+	writeHistoryRelease(c, "console.log('release 1');")
+	writeHistoryRelease(c, "console.log('release 2');")
+	PrintFile(historyDist + "/index.html")
+
+	releases, err := ReadHistory(historyDist)
+	if err != nil {
+		panic(err)
+	}
+	for _, r := range releases {
+		fmt.Println(r.ID, r.PV, r.UpdatedFiles)
+	}
+
+	err = Rollback(c, releases[0].ID)
+	if err != nil {
+		panic(err)
+	}
+	PrintFile(historyDist + "/index.html")
+
+	releases, err = ReadHistory(historyDist)
+	if err != nil {
+		panic(err)
+	}
+	fmt.Println(len(releases), releases[2].ID, releases[2].Rollback)
+
+	// Output:
+	// File test/history/dist/index.html:
+	// ////////////////
+	// <script src="app~fv=RhQF-wGL.js"></script>
+	// ////////////////
+	// wkHsmLRZ map[app.js:g_3r651a] [test/history/dist/index.html]
+	// i-01-F6Z map[app.js:RhQF-wGL] [test/history/dist/index.html]
+	// File test/history/dist/index.html:
+	// ////////////////
+	// <script src="app~fv=g_3r651a.js"></script>
+	// ////////////////
+	// 3 wkHsmLRZ wkHsmLRZ
+}
+
+// TestRollback_errors tests the errors of Rollback for an unknown release and
+// for a release whose versioned files were not retained.
+func TestRollback_errors(t *testing.T) {
+	err := os.RemoveAll(rollbackErrorsDist)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.MkdirAll(rollbackErrorsDist, 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := &Config{Src: dummySrc, Dist: rollbackErrorsDist, History: true}
+	err = VersionReplace(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	releases, err := ReadHistory(rollbackErrorsDist)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var ce *ConfigError
+	err = Rollback(c, "unknown")
+	if !errors.As(err, &ce) || ce.Field != "releaseID" {
+		t.Errorf("Rollback(unknown) = %v, want *ConfigError", err)
+	}
+
+	name := filepath.Join(rollbackErrorsDist, filepath.FromSlash(c.Info.VersionedFiles[0]))
+	err = os.Remove(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = Rollback(c, releases[0].ID)
+	var fe *FileOpError
+	if !errors.As(err, &ce) || !errors.As(err, &fe) || fe.Path != name || !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Rollback(%s) = %v, want *ConfigError wrapping *FileOpError for %s", releases[0].ID, err, name)
+	}
+}
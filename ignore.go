@@ -0,0 +1,156 @@
+package filever
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/bmatcuk/doublestar/v4"
+)
+
+// IgnoreFile is the name of the gitignore-style file, in `src` or `dist`, of
+// patterns of files ignored by FileVer.  Patterns without a `/` match at any
+// depth, a leading `/` anchors to the directory, a trailing `/` only matches
+// directories, and `!` re-includes.  Lines starting with `#` are comments.
+var IgnoreFile = ".fvignore"
+
+// filter decides which files of a directory tree are walked, from
+// Config.Include, Config.Exclude and the tree's IgnoreFile.
+type filter struct {
+	include []string
+	rules   []ignoreRule
+}
+
+// ignoreRule is an exclude pattern.  The last matching rule wins.
+//
+//	pattern - doublestar pattern relative to the tree root.
+//	negate  - If true, a match re-includes.
+//	dirOnly - If true, only matches directories.
+type ignoreRule struct {
+	pattern string
+	negate  bool
+	dirOnly bool
+}
+
+// newFilter returns the filter for the tree at root from c, which may be nil,
+// and root's IgnoreFile, if any.
+func newFilter(c *Config, root string) (f *filter, err error) {
+	f = new(filter)
+	if c != nil {
+		f.include = c.Include
+		for _, p := range c.Exclude {
+			f.rules = append(f.rules, ignoreRule{pattern: p})
+		}
+	}
+
+	b, err := os.ReadFile(filepath.Join(root, IgnoreFile))
+	if os.IsNotExist(err) {
+		return f, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	for _, line := range strings.Split(string(b), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		f.rules = append(f.rules, parseIgnoreLine(line))
+	}
+	return f, nil
+}
+
+// parseIgnoreLine returns the rule for a line of an IgnoreFile.
+func parseIgnoreLine(line string) (r ignoreRule) {
+	if strings.HasPrefix(line, "!") {
+		r.negate = true
+		line = line[1:]
+	}
+	if strings.HasSuffix(line, "/") {
+		r.dirOnly = true
+		line = strings.TrimSuffix(line, "/")
+	}
+	if strings.HasPrefix(line, "/") {
+		line = line[1:]
+	} else if !strings.Contains(line, "/") {
+		line = "**/" + line
+	}
+	r.pattern = line
+	return r
+}
+
+// skip reports whether the file or directory at rel, slash separated and
+// relative to the tree root, is skipped.  Include patterns only apply to files.
+// IgnoreFile itself is always skipped.
+func (f *filter) skip(rel string, dir bool) bool {
+	if rel == "." || rel == "" {
+		return false
+	}
+	if !dir && path.Base(rel) == IgnoreFile {
+		return true
+	}
+	excluded := false
+	for _, r := range f.rules {
+		if r.dirOnly && !dir {
+			continue
+		}
+		if ok, _ := doublestar.Match(r.pattern, rel); ok {
+			excluded = !r.negate
+		}
+	}
+	if excluded {
+		return true
+	}
+	if dir || len(f.include) == 0 {
+		return false
+	}
+	for _, p := range f.include {
+		if ok, _ := doublestar.Match(p, rel); ok {
+			return false
+		}
+	}
+	return true
+}
+
+// excluded reports whether the file at rel, slash separated and relative to
+// the tree root, is skipped, including by any of its parent directories.
+func (f *filter) excluded(rel string) bool {
+	for dir := path.Dir(rel); dir != "."; dir = path.Dir(dir) {
+		if f.skip(dir, true) {
+			return true
+		}
+	}
+	return f.skip(rel, false)
+}
+
+// walkFiles walks the files in root, skipping MetaDir and files skipped by f.
+// fn is given each file's path relative to pwd and rel, slash separated and
+// relative to root.  The walk stops with ctx.Err() if ctx is done.
+func walkFiles(ctx context.Context, root string, f *filter, fn func(path, rel string, d fs.DirEntry) error) error {
+	return filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if d.IsDir() {
+			if d.Name() == MetaDir || f.skip(rel, true) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if f.skip(rel, false) {
+			return nil
+		}
+		return fn(p, rel, d)
+	})
+}
@@ -0,0 +1,57 @@
+package filever
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// ExampleVersionReplace_ignore demonstrates Config.Exclude and IgnoreFile
+// skipping files in src and dist.
+func ExampleVersionReplace_ignore() {
+	for _, d := range []string{ignoreSrc, ignoreDist} {
+		err := os.RemoveAll(d)
+		if err != nil {
+			panic(err)
+		}
+	}
+	ref := `<script src="/app~fv=00000000.js"></script>`
+	files := map[string]string{
+		ignoreSrc + "/" + IgnoreFile:                "# Work in progress.\ndrafts/\n",
+		ignoreSrc + "/app~fv=00000000.js":           "console.log('app');",
+		ignoreSrc + "/drafts/wip~fv=00000000.js":    "console.log('wip');",
+		ignoreDist + "/" + IgnoreFile:               "node_modules/\n",
+		ignoreDist + "/index.html":                  ref,
+		ignoreDist + "/node_modules/pkg/index.html": ref,
+		ignoreDist + "/vendor/lib/index.html":       ref,
+	}
+	for name, content := range files {
+		err := os.MkdirAll(filepath.Dir(name), 0755)
+		if err != nil {
+			panic(err)
+		}
+		err = os.WriteFile(name, []byte(content), 0644)
+		if err != nil {
+			panic(err)
+		}
+	}
+
+	c := &Config{Src: ignoreSrc, Dist: ignoreDist, Exclude: []string{"vendor/**/index.html"}}
+	err := VersionReplace(c)
+	if err != nil {
+		panic(err)
+	}
+	fmt.Println(c.SrcFiles)
+	fmt.Println(c.Info.VersionedFiles)
+	fmt.Println(c.Info.UpdatedFilePaths)
+	PrintFile(ignoreDist + "/node_modules/pkg/index.html")
+
+	// Output:
+	// [app~fv=00000000.js]
+	// [app~fv=NVoPWitK.js]
+	// [test/ignore/dist/index.html]
+	// File test/ignore/dist/node_modules/pkg/index.html:
+	// ////////////////
+	// <script src="/app~fv=00000000.js"></script>
+	// ////////////////
+}
@@ -0,0 +1,157 @@
+package filever
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/cyphrme/coze"
+)
+
+// LockFile is the default path, relative to pwd, of the lock file.  See
+// Config.LockPath.
+var LockFile = "filever.lock"
+
+// LockMode is the Version() behavior for the lock file, which records the
+// version of each file and is meant to be committed, so that reviewers see
+// which versioned files a change affects.
+type LockMode int
+
+const (
+	// LockOff neither reads nor writes the lock file.  Default.
+	LockOff LockMode = iota
+	// LockFrozen reads the lock file and makes Version() return a *LockError
+	// for each file whose version differs from the lock, including files
+	// added or removed, e.g. to fail a CI build.  Files with a differing
+	// version are not copied into c.Dist.
+	LockFrozen
+	// LockUpdate makes Version() rewrite the lock file.
+	LockUpdate
+)
+
+// Lock is the content of the lock file.
+//
+//	Files - Locked files by bare path, relative to c.Dist.
+type Lock struct {
+	Files map[string]LockEntry
+}
+
+// LockEntry is a locked file.
+//
+//	Version - Version of the file.
+//	Alg     - Hash alg of the version, e.g. `SHA-256`.
+//	Size    - Size, in bytes, of the src file.
+type LockEntry struct {
+	Version string
+	Alg     coze.HshAlg
+	Size    int
+}
+
+// LockError is a version differing from the lock file in LockFrozen mode.
+//
+//	BarePath - Bare path of the file, relative to c.Dist.
+//	Locked   - Locked version.  Empty if the file is not in the lock.
+//	Version  - Computed version.  Empty if the file was removed.
+type LockError struct {
+	BarePath string
+	Locked   string
+	Version  string
+}
+
+func (e *LockError) Error() string {
+	switch {
+	case e.Locked == "":
+		return fmt.Sprintf("%s is not in the lock file (version %s)", e.BarePath, e.Version)
+	case e.Version == "":
+		return fmt.Sprintf("%s is in the lock file (version %s) but was not versioned", e.BarePath, e.Locked)
+	}
+	return fmt.Sprintf("%s version %s differs from locked version %s", e.BarePath, e.Version, e.Locked)
+}
+
+// ReadLock reads the lock file at path.
+func ReadLock(path string) (l *Lock, err error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	l = new(Lock)
+	err = json.Unmarshal(b, l)
+	if err != nil {
+		return nil, fmt.Errorf("lock file %s: %w", path, err)
+	}
+	if l.Files == nil {
+		l.Files = map[string]LockEntry{}
+	}
+	return l, nil
+}
+
+// WriteLock writes l to the lock file at path.  Files are sorted by bare
+// path, so that the lock diffs well.
+func WriteLock(path string, l *Lock) error {
+	b, err := json.MarshalIndent(l, "", "\t")
+	if err != nil {
+		return err
+	}
+	return writeFile(path, append(b, '\n'), 0644)
+}
+
+// lockPath returns c.LockPath, or LockFile if not set.
+func lockPath(c *Config) string {
+	if c.LockPath != "" {
+		return c.LockPath
+	}
+	return LockFile
+}
+
+// startLock prepares the lock of a run according to c.Lock.  For LockFrozen,
+// the lock file is read.
+func startLock(c *Config) (err error) {
+	switch c.Lock {
+	case LockFrozen:
+		c.lock, err = ReadLock(lockPath(c))
+		if err != nil {
+			return &ConfigError{Field: "c.Lock", Msg: "is LockFrozen but the lock file could not be read", Err: err}
+		}
+	case LockUpdate:
+		c.lock = &Lock{Files: map[string]LockEntry{}}
+	default:
+		c.lock = nil
+	}
+	return nil
+}
+
+// lockFile checks, for LockFrozen, or records, for LockUpdate, the version of
+// bare.
+func lockFile(c *Config, bare, version string, size int) error {
+	if c.lock == nil {
+		return nil
+	}
+	if c.Lock == LockUpdate {
+		c.lock.Files[bare] = LockEntry{Version: version, Alg: HashAlg, Size: size}
+		return nil
+	}
+	e, ok := c.lock.Files[bare]
+	if !ok || e.Version != version {
+		return &LockError{BarePath: bare, Locked: e.Version, Version: version}
+	}
+	return nil
+}
+
+// endLock returns, for LockFrozen, a *LockError for each locked file not in
+// c.Info.PV, or writes, for LockUpdate, the lock file.
+func endLock(c *Config) error {
+	if c.lock == nil {
+		return nil
+	}
+	if c.Lock == LockUpdate {
+		return WriteLock(lockPath(c), c.lock)
+	}
+	var errs []error
+	for _, bare := range sortedKeys(c.lock.Files) {
+		if _, ok := c.Info.PV[bare]; !ok {
+			errs = append(errs, &LockError{BarePath: bare, Locked: c.lock.Files[bare].Version})
+		}
+	}
+	return errors.Join(errs...)
+}
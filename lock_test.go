@@ -0,0 +1,79 @@
+package filever
+
+import (
+	"errors"
+	"fmt"
+	"os"
+)
+
+// ExampleLock demonstrates writing the lock file and versioning against it.
+func ExampleLock() {
+	lockPath := lockDist + ".json"
+	for _, name := range []string{lockDist, lockPath} {
+		err := os.RemoveAll(name)
+		if err != nil {
+			panic(err)
+		}
+	}
+	err := os.MkdirAll(lockDist, 0755)
+	if err != nil {
+		panic(err)
+	}
+
+	err = VersionReplace(&Config{Src: dummySrc, Dist: lockDist, Lock: LockUpdate, LockPath: lockPath})
+	if err != nil {
+		panic(err)
+	}
+	b, err := os.ReadFile(lockPath)
+	if err != nil {
+		panic(err)
+	}
+	fmt.Print(string(b))
+
+	err = VersionReplace(&Config{Src: dummySrc, Dist: lockDist, Lock: LockFrozen, LockPath: lockPath})
+	fmt.Println(err)
+
+	// Lock a different version.
+	l, err := ReadLock(lockPath)
+	if err != nil {
+		panic(err)
+	}
+	l.Files["test_1.js"] = LockEntry{Version: "Ab7ZeAgj", Alg: HashAlg, Size: 305}
+	err = WriteLock(lockPath, l)
+	if err != nil {
+		panic(err)
+	}
+	err = VersionReplace(&Config{Src: dummySrc, Dist: lockDist, Lock: LockFrozen, LockPath: lockPath})
+	var le *LockError
+	fmt.Println(errors.As(err, &le))
+	fmt.Println(err)
+
+	// Output:
+	// {
+	// 	"Files": {
+	// 		"subdir/test_3.js": {
+	// 			"Version": "_X83uO__",
+	// 			"Alg": "SHA-256",
+	// 			"Size": 159
+	// 		},
+	// 		"subdir/test_4.js": {
+	// 			"Version": "GJIrg6k1",
+	// 			"Alg": "SHA-256",
+	// 			"Size": 425
+	// 		},
+	// 		"test_1.js": {
+	// 			"Version": "vPCb4GVO",
+	// 			"Alg": "SHA-256",
+	// 			"Size": 305
+	// 		},
+	// 		"test_2.js": {
+	// 			"Version": "BOl7h9TM",
+	// 			"Alg": "SHA-256",
+	// 			"Size": 304
+	// 		}
+	// 	}
+	// }
+	// <nil>
+	// true
+	// test_1.js version vPCb4GVO differs from locked version Ab7ZeAgj
+}
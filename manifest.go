@@ -0,0 +1,165 @@
+package filever
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/cyphrme/coze"
+)
+
+// MetaDir is the directory in `dist` holding FileVer metadata, such as
+// manifests.  MetaDir is not walked by Replace() and should not be served.
+var MetaDir = ".filever"
+
+// ManifestFile is the name of the current manifest in MetaDir.
+var ManifestFile = "manifest.json"
+
+// ManifestsDir is the directory in MetaDir holding previous manifests.
+var ManifestsDir = "manifests"
+
+// IntegrityAlg is the hash alg used for Subresource Integrity.
+var IntegrityAlg = coze.SHA384
+
+// Manifest records the versions of a run.
+type Manifest struct {
+	Created time.Time
+
+	// PV "Path:Version", the same as Info.PV.  E.g. `"test_1.js":"4WYoW0MN"`
+	PV map[string]string
+
+	// Integrity is "Path:Subresource Integrity" of the versioned files in
+	// `dist`.  E.g. `"test_1.js":"sha384-oqVuAfXRKap7fdgcCY5uykM6+R9GqQ8K/uxy9rx7HNQlGYl1kPzQho1wx4JwY8wC"`
+	Integrity map[string]string `json:",omitempty"`
+}
+
+// NewManifest returns a manifest from c.Info.  c.Info must be set.  Integrity
+// is calculated from the versioned files in c.Dist, so NewManifest should be
+// called after Replace().  Versioned files missing from c.Dist have no
+// integrity.
+func NewManifest(c *Config) (m *Manifest, err error) {
+	if c.Info == nil {
+		return nil, &ConfigError{Field: "c.Info", Msg: "must be set"}
+	}
+	m = &Manifest{Created: time.Now().UTC(), PV: map[string]string{}, Integrity: map[string]string{}}
+	for k, v := range c.Info.PV {
+		m.PV[k] = v
+		fv, _ := genFileVer(k, v, c)
+		b, err := os.ReadFile(filepath.Join(c.Dist, filepath.FromSlash(fv)))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		m.Integrity[k], err = Integrity(b)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+// Integrity returns the Subresource Integrity value of b using IntegrityAlg,
+// e.g. `sha384-oqVuAfXRKap7fdgcCY5uykM6+R9GqQ8K/uxy9rx7HNQlGYl1kPzQho1wx4JwY8wC`.
+func Integrity(b []byte) (string, error) {
+	d, err := coze.Hash(IntegrityAlg, b)
+	if err != nil {
+		return "", err
+	}
+	return strings.ToLower(strings.ReplaceAll(IntegrityAlg.String(), "-", "")) + "-" + base64.StdEncoding.EncodeToString(d), nil
+}
+
+// FileVer returns the pathed FileVer for the bare path, e.g. `e/app.min.js`
+// returns `e/app~fv=4mIbJJPq.min.js`.  Returns ok false if bare path is not in
+// the manifest.
+func (m *Manifest) FileVer(barePath string) (fileVer string, ok bool) {
+	v, ok := m.PV[barePath]
+	if !ok {
+		return "", false
+	}
+	fv, _ := genFileVer(barePath, v, nil)
+	return fv, true
+}
+
+// WriteManifest writes the manifest for c.Info to
+// `c.Dist/MetaDir/ManifestFile`, and a copy, for history, into
+// `c.Dist/MetaDir/ManifestsDir`.  Copies are removed by Prune().
+func WriteManifest(c *Config) (err error) {
+	m, err := NewManifest(c)
+	if err != nil {
+		return err
+	}
+	b, err := json.MarshalIndent(m, "", "\t")
+	if err != nil {
+		return err
+	}
+
+	dir := filepath.Join(c.Dist, MetaDir, ManifestsDir)
+	err = os.MkdirAll(dir, 0755)
+	if err != nil {
+		return err
+	}
+	err = os.WriteFile(filepath.Join(dir, fmt.Sprintf("%d.json", m.Created.UnixNano())), b, 0644)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(c.Dist, MetaDir, ManifestFile), b, 0644)
+}
+
+// ReadManifest reads the manifest at path, e.g.
+// `dist/.filever/manifest.json`.
+func ReadManifest(path string) (m *Manifest, err error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	m = new(Manifest)
+	return m, json.Unmarshal(b, m)
+}
+
+// ReadManifests returns the previous manifests in `dist`, newest first.
+func ReadManifests(dist string) (ms []*Manifest, err error) {
+	copies, err := manifestCopies(dist)
+	for _, mc := range copies {
+		ms = append(ms, mc.m)
+	}
+	return ms, err
+}
+
+// manifestCopy is a previous manifest in ManifestsDir.
+type manifestCopy struct {
+	path string // Relative to pwd.
+	m    *Manifest
+}
+
+// manifestCopies returns the previous manifests in `dist`, newest first.
+func manifestCopies(dist string) (copies []manifestCopy, err error) {
+	dir := filepath.Join(dist, MetaDir, ManifestsDir)
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), ".json") {
+			continue
+		}
+		name := filepath.Join(dir, e.Name())
+		m, err := ReadManifest(name)
+		if err != nil {
+			return nil, err
+		}
+		copies = append(copies, manifestCopy{name, m})
+	}
+	sort.Slice(copies, func(i, j int) bool { return copies[i].m.Created.After(copies[j].m.Created) })
+	return copies, nil
+}
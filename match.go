@@ -0,0 +1,200 @@
+package filever
+
+import (
+	"bytes"
+	"path/filepath"
+	"unicode"
+	"unicode/utf8"
+)
+
+// refMatcher finds references to versioned files in source files.  Matches
+// follow the reference grammar.  See FileVerPathReg.  *regexp.Regexp is a
+// refMatcher.
+type refMatcher interface {
+	FindAllIndex(b []byte, n int) [][]int
+}
+
+// literalMatcher is the refMatcher for c.UseSAVR.  It matches references to
+// the bare file names of versioned files, e.g. `app.min.js` for
+// `../e/app~fv=4mIbJJPq.min.js?v=1`, in any directory, without compiling a
+// regex with an alternative per versioned file like the former SAVR regex.
+// Since the referencing file is needed to resolve a reference, matches are
+// candidates: currentRef() leaves matches not resolving to the bare path of a
+// versioned file, e.g. `vendor/app~fv=4mIbJJPq.min.js`, alone.
+//
+// Every reference has Delim, so instead of an automaton over all file names,
+// literalMatcher finds each Delim and looks up the file name before it (the
+// prefix) and the extension after it (the suffix) in names, which is a single
+// linear pass over the source file regardless of the number of versioned
+// files.  The directory, scheme, host, query and fragment are then matched
+// like FileVerPathReg.
+type literalMatcher struct {
+	delim []byte
+	names map[string]bool // Bare file names, e.g. `app.min.js`.
+}
+
+// newLiteralMatcher returns the literalMatcher for c.Info.VersionedFiles.
+func newLiteralMatcher(c *Config) *literalMatcher {
+	m := &literalMatcher{delim: []byte(Delim), names: map[string]bool{}}
+	for _, f := range c.Info.VersionedFiles {
+		m.names[Populated(filepath.ToSlash(f)).BareFile] = true
+	}
+	return m
+}
+
+// FindAllIndex returns the start and end of successive references in b to
+// versioned files, at most n if n >= 0, like regexp.Regexp.FindAllIndex.
+func (m *literalMatcher) FindAllIndex(b []byte, n int) (matches [][]int) {
+	last := 0 // End of the last match.  Matches don't overlap.
+	for i := 0; n < 0 || len(matches) < n; {
+		d := bytes.Index(b[i:], m.delim)
+		if d == -1 {
+			break
+		}
+		d += i
+		i = d + len(m.delim)
+
+		name := d
+		for name > last {
+			r, size := utf8.DecodeLastRune(b[:name])
+			if !isRefNameRune(b, name-size, r) {
+				break
+			}
+			name -= size
+		}
+		ver := i
+		for ver < len(b) && isVersionByte(b[ver]) {
+			ver++
+		}
+		ext := ver
+		for ext < len(b) && b[ext] == '.' && ext+1 < len(b) && isVersionByte(b[ext+1]) {
+			ext++
+			for ext < len(b) && isVersionByte(b[ext]) {
+				ext++
+			}
+		}
+		if !m.names[string(b[name:d])+string(b[ver:ext])] {
+			continue
+		}
+
+		end := refQueryEnd(b, ext)
+		matches = append(matches, []int{refStart(b, last, name), end})
+		i, last = end, end
+	}
+	return matches
+}
+
+// isRefNameRune reports whether r, at b[i], is a file name character of a
+// reference, `nchar` of the reference grammar.
+func isRefNameRune(b []byte, i int, r rune) bool {
+	switch {
+	case r >= utf8.RuneSelf:
+		return !unicode.In(r, unicode.Z, unicode.Pi, unicode.Pf)
+	case r == '%':
+		return i+2 < len(b) && isHex(b[i+1]) && isHex(b[i+2])
+	case isVersionByte(byte(r)):
+		return true
+	}
+	switch r {
+	case '~', '@', '!', '$', '+':
+		return true
+	}
+	return false
+}
+
+// isRefPathRune reports whether r, at b[i], is a path character of a
+// reference, `pchar` of the reference grammar.
+func isRefPathRune(b []byte, i int, r rune) bool {
+	return r == '.' || r == '/' || isRefNameRune(b, i, r)
+}
+
+// isVersionByte reports whether c is a version or extension character.
+func isVersionByte(c byte) bool {
+	return '0' <= c && c <= '9' || 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z' || c == '_' || c == '-'
+}
+
+func isHex(c byte) bool {
+	return '0' <= c && c <= '9' || 'A' <= c && c <= 'F' || 'a' <= c && c <= 'f'
+}
+
+// isHostByte reports whether c is a host or scheme character.
+func isHostByte(c byte, scheme bool) bool {
+	return isVersionByte(c) && c != '_' || c == '.' || scheme && c == '+'
+}
+
+// refStart returns the start, not before lo, of the reference in b with the
+// file name starting at i, including the directory, host and scheme.
+func refStart(b []byte, lo, i int) int {
+	name := i
+	if i == lo || b[i-1] != '/' {
+		return i
+	}
+	for i > lo {
+		r, size := utf8.DecodeLastRune(b[:i])
+		if !isRefPathRune(b, i-size, r) {
+			break
+		}
+		i -= size
+	}
+	if i == lo || b[i-1] != ':' {
+		return i
+	}
+
+	// Port, e.g. `:8080`, of `scheme://host:8080/`.
+	if '0' <= b[i] && b[i] <= '9' {
+		host := i - 1
+		for host > lo && isHostByte(b[host-1], false) {
+			host--
+		}
+		if host == i-1 || host-3 < lo || string(b[host-3:host]) != "://" {
+			return i
+		}
+		if s := schemeStart(b, lo, host-3); s != -1 {
+			return s
+		}
+		return i
+	}
+
+	// `scheme:` of `scheme://host/`.
+	if bytes.HasPrefix(b[i:], []byte("//")) && i+2 < name-1 && isHostByte(b[i+2], false) {
+		if s := schemeStart(b, lo, i-1); s != -1 {
+			return s
+		}
+	}
+	return i
+}
+
+// schemeStart returns the start, not before lo, of the scheme ending at b[i],
+// which is `:`, or -1 if there is no scheme.
+func schemeStart(b []byte, lo, i int) int {
+	s := -1
+	for j := i - 1; j >= lo && isHostByte(b[j], true); j-- {
+		if 'A' <= b[j] && b[j] <= 'Z' || 'a' <= b[j] && b[j] <= 'z' {
+			s = j
+		}
+	}
+	return s
+}
+
+// refQueryEnd returns the end of the query and fragment, if any, starting at
+// b[i].
+func refQueryEnd(b []byte, i int) int {
+	if i < len(b) && b[i] == '?' {
+		for i++; i < len(b) && isQueryByte(b[i]); i++ {
+		}
+	}
+	if i < len(b) && b[i] == '#' {
+		for i++; i < len(b) && isQueryByte(b[i]); i++ {
+		}
+	}
+	return i
+}
+
+// isQueryByte reports whether c is a query or fragment character.
+func isQueryByte(c byte) bool {
+	switch c {
+	case ' ', '\t', '\n', '\f', '\r', '"', '\'', '(', ')', '<', '>', '`', '\\', '#':
+		return false
+	}
+	return true
+}
@@ -0,0 +1,205 @@
+package filever
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+// Example_literalMatcher demonstrates the literal matcher used for
+// Config.UseSAVR, which matches only references to versioned files.
+func Example_literalMatcher() {
+	c := &Config{UseSAVR: true, Info: &Info{VersionedFiles: []string{"e/app~fv=4mIbJJPq.min.js", "style~fv=l1E3Agye.css"}}}
+	err := genSrcReg(c)
+	if err != nil {
+		panic(err)
+	}
+	b := []byte(`<script src="https://cdn.example.com:8080/e/app~fv=00000000.min.js?v=1"></script>
+<script src="../e/app~fv=00000000.min.js.map"></script>
+<link href='v1.2/style~fv=00000000.css#dark'><img src="logo~fv=00000000.png">
+`)
+	for _, m := range c.refs.FindAllIndex(b, -1) {
+		fmt.Println(string(b[m[0]:m[1]]))
+	}
+
+	// Output:
+	// https://cdn.example.com:8080/e/app~fv=00000000.min.js?v=1
+	// v1.2/style~fv=00000000.css#dark
+}
+
+// TestLiteralMatcher_resolve tests that, with UseSAVR, a reference with the
+// file name of a versioned file that doesn't resolve to it is left alone and
+// isn't dangling.
+func TestLiteralMatcher_resolve(t *testing.T) {
+	for _, d := range []string{literalSrc, literalDist} {
+		err := os.RemoveAll(d)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = os.MkdirAll(d, 0755)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	err := os.WriteFile(literalSrc+"/app~fv=00000000.js", []byte("// App.\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	index := literalDist + "/index.html"
+	err = os.WriteFile(index, []byte(`<script src="app~fv=00000000.js"></script><script src="vendor/app~fv=00000000.js"></script>`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := &Config{Src: literalSrc, Dist: literalDist, UseSAVR: true}
+	err = VersionReplace(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := os.ReadFile(index)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `<script src="app~fv=` + c.Info.PV["app.js"] + `.js"></script><script src="vendor/app~fv=00000000.js"></script>`
+	if string(b) != want {
+		t.Errorf("index.html = %s, want %s", b, want)
+	}
+	if c.Info.TotalSourceReplaces != 1 || len(c.Info.DanglingRefs) != 0 {
+		t.Errorf("TotalSourceReplaces = %d, DanglingRefs = %v, want 1 and none", c.Info.TotalSourceReplaces, c.Info.DanglingRefs)
+	}
+}
+
+// FuzzLiteralMatcher checks that the literal matcher matches like
+// FileVerPathReg when every referenced file is versioned.
+func FuzzLiteralMatcher(f *testing.F) {
+	f.Add(`<a href="v1.2/app~fv=4mIbJJPq.min.js?x">`)
+	f.Add(`url(@scope/é/my%20app~fv=4mIbJJPqX.css#a) a.b~fv=1.c/d~fv=2`)
+	f.Add(`x https://h:80/a~fv=1.js?q~fv=2 //h/b~fv=.js 1://h/c~fv= f+t://h:8x/d~fv=`)
+	f.Add(`00000000000~fv=0~fv=`)
+	f.Add(`0A://0~fv=0`)
+	f.Add(`~fv=A://0/~fv=`)
+	ref := regexp.MustCompile(FileVerPathReg)
+	f.Fuzz(func(t *testing.T, ts string) {
+		if !utf8.ValidString(ts) {
+			t.Skip()
+		}
+		b := []byte(ts)
+		want := ref.FindAllIndex(b, -1)
+		c := &Config{UseSAVR: true, Info: new(Info)}
+		for _, m := range want {
+			r, _, _ := strings.Cut(string(b[m[0]:m[1]]), "?")
+			r, _, _ = strings.Cut(r, "#")
+			c.Info.VersionedFiles = append(c.Info.VersionedFiles, Populated(r).File)
+		}
+		got := newLiteralMatcher(c).FindAllIndex(b, -1)
+		if len(got) != 0 || len(want) != 0 {
+			if !reflect.DeepEqual(got, want) {
+				t.Fatalf("FindAllIndex(%q) = %v, want %v", ts, got, want)
+			}
+		}
+	})
+}
+
+// savr returns the former SAVR regex, with an alternative per versioned file,
+// for comparison.
+func savr(c *Config) string {
+	var alts []string
+	seen := map[string]bool{}
+	for _, k := range c.Info.VersionedFiles {
+		nv := VerAnySizeRegexC.ReplaceAllString(filepath.Base(k), "")
+		if !seen[nv] {
+			seen[nv] = true
+			alts = append(alts, refDirReg+genFileVerRegex(nv, c)+refQueryReg)
+		}
+	}
+	return strings.Join(alts, "|")
+}
+
+// benchSizes are the numbers of versioned files benchmarked.
+var benchSizes = []int{10, 100, 1000}
+
+// benchConfig returns a Config with n versioned files and a source file with
+// 100 references, to every n/100th versioned file, among text.
+func benchConfig(n int) (c *Config, src []byte) {
+	c = &Config{UseSAVR: true, Info: new(Info)}
+	for i := 0; i < n; i++ {
+		c.Info.VersionedFiles = append(c.Info.VersionedFiles, fmt.Sprintf("e/asset_%d~fv=4mIbJJPq.min.js", i))
+	}
+	var sb strings.Builder
+	for i := 0; i < 100; i++ {
+		fmt.Fprintf(&sb, "<script src=\"../e/asset_%d~fv=00000000.min.js\"></script>\n", i*n/100)
+		sb.WriteString("<p>Some text, e.g. app.min.js or https://example.com/a/b?c=d, between references.</p>\n")
+	}
+	return c, []byte(sb.String())
+}
+
+func BenchmarkFileVerPathReg(b *testing.B) {
+	for _, n := range benchSizes {
+		b.Run(fmt.Sprint(n), func(b *testing.B) {
+			_, src := benchConfig(n)
+			reg := regexp.MustCompile(FileVerPathReg)
+			b.SetBytes(int64(len(src)))
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				reg.FindAllIndex(src, -1)
+			}
+		})
+	}
+}
+
+func BenchmarkSAVR(b *testing.B) {
+	for _, n := range benchSizes {
+		b.Run(fmt.Sprint(n), func(b *testing.B) {
+			c, src := benchConfig(n)
+			reg := regexp.MustCompile(savr(c))
+			b.SetBytes(int64(len(src)))
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				reg.FindAllIndex(src, -1)
+			}
+		})
+	}
+}
+
+func BenchmarkLiteralMatcher(b *testing.B) {
+	for _, n := range benchSizes {
+		b.Run(fmt.Sprint(n), func(b *testing.B) {
+			c, src := benchConfig(n)
+			m := newLiteralMatcher(c)
+			b.SetBytes(int64(len(src)))
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				m.FindAllIndex(src, -1)
+			}
+		})
+	}
+}
+
+// BenchmarkSAVRCompile and BenchmarkLiteralMatcherBuild compare the cost of
+// building the matchers, once per Replace().
+func BenchmarkSAVRCompile(b *testing.B) {
+	for _, n := range benchSizes {
+		b.Run(fmt.Sprint(n), func(b *testing.B) {
+			c, _ := benchConfig(n)
+			for i := 0; i < b.N; i++ {
+				regexp.MustCompile(savr(c))
+			}
+		})
+	}
+}
+
+func BenchmarkLiteralMatcherBuild(b *testing.B) {
+	for _, n := range benchSizes {
+		b.Run(fmt.Sprint(n), func(b *testing.B) {
+			c, _ := benchConfig(n)
+			for i := 0; i < b.N; i++ {
+				newLiteralMatcher(c)
+			}
+		})
+	}
+}
@@ -0,0 +1,249 @@
+package filever
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	path "github.com/cyphrme/path"
+)
+
+// Format is the position of the version in a FileVer.
+type Format int
+
+const (
+	// MidVer places the version after the base and before the extension, e.g.
+	// `app~fv=4mIbJJPq.min.js`.  This is the default format.
+	MidVer Format = iota
+	// EndVer places the version at the end of the file name, e.g.
+	// `app.min.js?fv=4mIbJJPq`.
+	EndVer
+)
+
+// Scheme is a FileVer naming scheme.  The default scheme is given by
+// DefaultScheme().
+type Scheme struct {
+	Delim       string
+	VersionSize int
+	Format      Format
+}
+
+// DefaultScheme returns the scheme currently used by the package, i.e. Delim,
+// VersionSize, and MidVer.
+func DefaultScheme() Scheme {
+	return Scheme{Delim: Delim, VersionSize: VersionSize, Format: MidVer}
+}
+
+// dummy returns the scheme's dummy version.
+func (s Scheme) dummy() string {
+	return strings.Repeat("0", s.VersionSize)
+}
+
+// refRegex returns the regex matching pathed FileVers, in any format, of the
+// scheme.  E.g. `../e/app~fv=00000000.min.js` or `../e/app.min.js?fv=00000000`.
+func (s Scheme) refRegex() (*regexp.Regexp, error) {
+	return regexp.Compile(`[0-9A-Za-z_\-\/.]*` + regexp.QuoteMeta(s.Delim) + `[0-9A-Za-z_-]*(?:\.[0-9A-Za-z_-]+)*`)
+}
+
+// split splits a pathed FileVer of the scheme into its bare path and version.
+// Both MidVer and EndVer FileVers are parsed.  Returns ok false if fv has no
+// delimiter.
+func (s Scheme) split(fv string) (barePath, version string, ok bool) {
+	before, after, ok := strings.Cut(fv, s.Delim)
+	if !ok {
+		return "", "", false
+	}
+	i := strings.IndexFunc(after, func(r rune) bool { return !isB64ut(r) })
+	if i == -1 {
+		i = len(after)
+	}
+	return before + after[i:], after[:i], true
+}
+
+// join returns the pathed FileVer of the bare path and version in the
+// scheme's format.
+func (s Scheme) join(barePath, version string) string {
+	if s.Format == EndVer {
+		return barePath + s.Delim + version
+	}
+	dir, file := path.PathCut(barePath)
+	base, ext, found := strings.Cut(file, ".")
+	if found {
+		ext = "." + ext
+	}
+	return dir + base + s.Delim + version + ext
+}
+
+// isB64ut reports whether r is a base64 URI truncated character.
+func isB64ut(r rune) bool {
+	return r >= '0' && r <= '9' || r >= 'A' && r <= 'Z' || r >= 'a' && r <= 'z' || r == '-' || r == '_'
+}
+
+// MigrateReport holds the results of Migrate().  Paths are relative to the
+// migrated directory.
+type MigrateReport struct {
+	// Renames is old file name:new file name.
+	Renames map[string]string
+
+	// Rewrites is file:number of references rewritten.  File is the old file
+	// name.
+	Rewrites map[string]int
+
+	// Conflicts are problems that prevent migration.  If any conflict exists,
+	// Migrate does not write.
+	Conflicts []string
+}
+
+// Migrate rewrites FileVer file names and in-file references in `directory`
+// (recursively) from one scheme to another, e.g. when changing Delim,
+// VersionSize, or the format (mid or end version).  Migrate is intended for
+// `src` trees where all versions are dummies.  Dummy versions are migrated to
+// the new scheme's dummy version.  Non-dummy versions are truncated if the new
+// version size is smaller, and are a conflict if the new size is larger since
+// digests are not available.
+//
+// If dryRun is true, or if any conflicts are detected, no files are written
+// and the report describes what would be done.  Conflicts are returned as a
+// *MigrateError when not a dry run.  Each new file is written atomically
+// before any old name is removed, so a failed write doesn't lose files.
+func Migrate(directory string, from, to Scheme, dryRun bool) (report *MigrateReport, err error) {
+	report = &MigrateReport{Renames: map[string]string{}, Rewrites: map[string]int{}}
+	fromReg, err := from.refRegex()
+	if err != nil {
+		return nil, err
+	}
+
+	conflicts := map[string]bool{} // The same FileVer may be referenced many times.
+	var conflict = func(msg string) {
+		if !conflicts[msg] {
+			conflicts[msg] = true
+			report.Conflicts = append(report.Conflicts, msg)
+		}
+	}
+
+	// migrate returns the migrated pathed FileVer.  ok is false on conflict.
+	var migrate = func(fv string) (out string, ok bool) {
+		bare, version, found := from.split(fv)
+		if !found {
+			return fv, true
+		}
+		switch {
+		case version == from.dummy():
+			version = to.dummy()
+		case len(version) >= to.VersionSize:
+			version = version[:to.VersionSize]
+		default:
+			conflict(fmt.Sprintf("Version %q in %s is too short for version size %d", version, fv, to.VersionSize))
+			return fv, false
+		}
+		return to.join(bare, version), true
+	}
+
+	contents := map[string][]byte{} // Old path:new content for files to write.
+	var walk = func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(directory, p)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+
+		b, err := os.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		count := 0
+		rewritten := fromReg.ReplaceAllFunc(b, func(ref []byte) []byte {
+			out, _ := migrate(string(ref))
+			if out != string(ref) {
+				count++
+			}
+			return []byte(out)
+		})
+		if count > 0 {
+			report.Rewrites[rel] = count
+			contents[rel] = rewritten
+		}
+
+		if strings.Contains(d.Name(), from.Delim) {
+			out, ok := migrate(rel)
+			if ok && out != rel {
+				report.Renames[rel] = out
+				contents[rel] = rewritten
+			}
+		}
+		return nil
+	}
+	err = filepath.WalkDir(directory, walk)
+	if err != nil {
+		return nil, err
+	}
+
+	// Conflicts: multiple files renamed to the same name or renamed onto a file
+	// that exists and is not itself renamed.
+	targets := map[string]string{}
+	for _, old := range sortedKeys(report.Renames) {
+		n := report.Renames[old]
+		if other, ok := targets[n]; ok {
+			conflict(fmt.Sprintf("%s and %s both migrate to %s", other, old, n))
+			continue
+		}
+		targets[n] = old
+		_, renamed := report.Renames[n]
+		_, err := os.Stat(filepath.Join(directory, filepath.FromSlash(n)))
+		if err == nil && !renamed {
+			conflict(fmt.Sprintf("%s migrates to existing file %s", old, n))
+		}
+	}
+
+	if dryRun {
+		return report, nil
+	}
+	if len(report.Conflicts) > 0 {
+		return report, &MigrateError{Conflicts: report.Conflicts}
+	}
+
+	// Write all files before removing old names.  Contents are in memory, so
+	// renames onto other renamed files are not clobbered.
+	for _, old := range sortedKeys(contents) {
+		n := old
+		if r, ok := report.Renames[old]; ok {
+			n = r
+		}
+		name := filepath.Join(directory, filepath.FromSlash(n))
+		err = writeFile(name, contents[old], 0644)
+		if err != nil {
+			return report, fileOpErr("write", name, err)
+		}
+	}
+	for _, old := range sortedKeys(report.Renames) {
+		if _, ok := targets[old]; ok { // Written by another rename.
+			continue
+		}
+		name := filepath.Join(directory, filepath.FromSlash(old))
+		err = os.Remove(name)
+		if err != nil {
+			return report, fileOpErr("remove", name, err)
+		}
+	}
+	return report, nil
+}
+
+// sortedKeys returns the sorted keys of m.
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
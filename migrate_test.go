@@ -0,0 +1,118 @@
+package filever
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+var dummyEndSrc = "test/old/dummy_end/src"
+var dummyEndDist = "test/old/dummy_end/dist"
+var migrateDir = "test/migrate" // For TestMigrate.  Generated by the test.
+
+// ExampleMigrate demonstrates migrating the old "end version" format, e.g.
+// `test_1.js?fv=00000000`, to the current format, `test_1~fv=00000000.js`.
+// Dry run does not write.
+func ExampleMigrate() {
+	from := Scheme{Delim: "?fv=", VersionSize: 8, Format: EndVer}
+	report, err := Migrate(dummyEndSrc, from, DefaultScheme(), true)
+	if err != nil {
+		panic(err)
+	}
+	PrintPretty(report)
+
+	// Output:
+	// {
+	// 	"Renames": {
+	// 		"subdir/test_3?fv=00000000.js": "subdir/test_3~fv=00000000.js",
+	// 		"subdir/test_4?fv=00000000.js": "subdir/test_4~fv=00000000.js",
+	// 		"test_1.js?fv=00000000": "test_1~fv=00000000.js",
+	// 		"test_2.js?fv=00000000": "test_2~fv=00000000.js"
+	// 	},
+	// 	"Rewrites": {
+	// 		"subdir/test_3?fv=00000000.js": 2,
+	// 		"subdir/test_4?fv=00000000.js": 4,
+	// 		"test_1.js?fv=00000000": 4,
+	// 		"test_2.js?fv=00000000": 4
+	// 	},
+	// 	"Conflicts": null
+	// }
+}
+
+// ExampleMigrate_conflicts demonstrates that versions can't be lengthened.
+func ExampleMigrate_conflicts() {
+	from := Scheme{Delim: "?fv=", VersionSize: 8, Format: EndVer}
+	to := Scheme{Delim: "~fv=", VersionSize: 12, Format: MidVer}
+	report, err := Migrate(dummyEndDist, from, to, false)
+	var me *MigrateError
+	fmt.Println(errors.As(err, &me))
+	PrintPretty(report.Renames)
+	PrintPretty(report.Conflicts)
+
+	// Output:
+	// true
+	// {}
+	// [
+	// 	"Version \"gGkfoWxG\" in subdir/test_3.js?fv=gGkfoWxG is too short for version size 12",
+	// 	"Version \"3DOHPqEi\" in subdir/test_4.js?fv=3DOHPqEi is too short for version size 12",
+	// 	"Version \"Jmp9dlP7\" in test_1.js?fv=Jmp9dlP7 is too short for version size 12",
+	// 	"Version \"ZbopKA8M\" in test_2.js?fv=ZbopKA8M is too short for version size 12"
+	// ]
+}
+
+// TestMigrate tests that Migrate writes renamed and rewritten files and removes
+// old names.
+func TestMigrate(t *testing.T) {
+	err := os.RemoveAll(migrateDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.MkdirAll(migrateDir, 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range map[string]string{
+		"a~fv=00000000.js": "import './b~fv=00000000.js';\nimport './b~fv=00000000.js';\n",
+		"b~fv=00000000.js": "// B.\n",
+		"index.html":       "<script src=\"a~fv=00000000.js\"></script>\n",
+	} {
+		err = os.WriteFile(filepath.Join(migrateDir, name), []byte(content), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	_, err = Migrate(migrateDir, DefaultScheme(), Scheme{Delim: Delim, VersionSize: 12, Format: MidVer}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	files, err := ListFilesInPath(migrateDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"a~fv=000000000000.js", "b~fv=000000000000.js", "index.html"}
+	if !reflect.DeepEqual(files, want) {
+		t.Fatalf("files = %v, want %v", files, want)
+	}
+	b, err := os.ReadFile(filepath.Join(migrateDir, "a~fv=000000000000.js"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := "import './b~fv=000000000000.js';\nimport './b~fv=000000000000.js';\n"; string(b) != s {
+		t.Errorf("a~fv=000000000000.js = %q, want %q", b, s)
+	}
+
+	// The same conflicting reference in two places is one conflict.
+	err = os.WriteFile(filepath.Join(migrateDir, "index.html"), []byte("<script src=\"c~fv=4mIbJJPq.js\"></script>\n<script src=\"c~fv=4mIbJJPq.js\"></script>\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	report, err := Migrate(migrateDir, Scheme{Delim: Delim, VersionSize: 12, Format: MidVer}, Scheme{Delim: Delim, VersionSize: 16, Format: MidVer}, false)
+	var me *MigrateError
+	if !errors.As(err, &me) || len(report.Conflicts) != 1 {
+		t.Errorf("Migrate() = %v, conflicts %q, want one conflict", err, report.Conflicts)
+	}
+}
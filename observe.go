@@ -0,0 +1,121 @@
+package filever
+
+import (
+	"context"
+	"log/slog"
+)
+
+// Event is an event emitted while versioning and replacing, given to
+// Config.Observer and logged to Config.Logger.  Event is one of FileHashed,
+// FileCopied, VersionDeleted, FileRewritten, or ReferenceUnresolved.
+type Event interface {
+	// attrs returns the log level, message and attributes of the event.
+	attrs() (slog.Level, string, []slog.Attr)
+}
+
+// Observer receives events, e.g. to render progress or collect metrics.
+// Observe is called synchronously.
+type Observer interface {
+	Observe(Event)
+}
+
+// ObserverFunc is a function Observer.
+type ObserverFunc func(Event)
+
+// Observe implements Observer.
+func (f ObserverFunc) Observe(e Event) { f(e) }
+
+// FileHashed is a src file hashed by Version().
+//
+//	Path    - File, relative to pwd.
+//	Version - Resulting version.
+type FileHashed struct {
+	Path    string
+	Version string
+}
+
+// FileCopied is a versioned file copied from src into dist by Version().
+//
+//	From - Src file, relative to pwd.
+//	To   - Versioned file, relative to pwd.
+type FileCopied struct {
+	From string
+	To   string
+}
+
+// VersionDeleted is a versioned file deleted from dist, e.g. a previous
+// version.
+//
+//	Path - File, relative to pwd.
+type VersionDeleted struct {
+	Path string
+}
+
+// FileRewritten is a file updated by Replace().
+//
+//	Path     - File, relative to pwd.
+//	Replaces - Number of references replaced.
+type FileRewritten struct {
+	Path     string
+	Replaces int
+}
+
+// ReferenceUnresolved is a dangling reference found by Replace().  It is
+// logged as a warning if Config.Dangling is DanglingWarn.
+//
+//	DanglingRef - The dangling reference.
+//	Policy      - Config.Dangling.
+type ReferenceUnresolved struct {
+	DanglingRef
+	Policy DanglingPolicy
+}
+
+func (e FileHashed) attrs() (slog.Level, string, []slog.Attr) {
+	return slog.LevelDebug, "file hashed", []slog.Attr{slog.String("path", e.Path), slog.String("version", e.Version)}
+}
+
+func (e FileCopied) attrs() (slog.Level, string, []slog.Attr) {
+	return slog.LevelDebug, "file copied", []slog.Attr{slog.String("from", e.From), slog.String("to", e.To)}
+}
+
+func (e VersionDeleted) attrs() (slog.Level, string, []slog.Attr) {
+	return slog.LevelDebug, "version deleted", []slog.Attr{slog.String("path", e.Path)}
+}
+
+func (e FileRewritten) attrs() (slog.Level, string, []slog.Attr) {
+	return slog.LevelDebug, "file rewritten", []slog.Attr{slog.String("path", e.Path), slog.Int("replaces", e.Replaces)}
+}
+
+func (e ReferenceUnresolved) attrs() (slog.Level, string, []slog.Attr) {
+	level := slog.LevelDebug
+	if e.Policy == DanglingWarn {
+		level = slog.LevelWarn
+	}
+	return level, "reference unresolved", []slog.Attr{
+		slog.String("path", e.Path),
+		slog.Int("line", e.Line),
+		slog.Int("column", e.Column),
+		slog.String("ref", e.Ref),
+	}
+}
+
+// emit gives e to c.Observer and logs it to c.Logger, and records it in the
+// report of the run.  If c.Logger is nil, warnings are logged to
+// slog.Default() and other events are not logged.  c may be nil.
+func emit(c *Config, e Event) {
+	if c == nil {
+		return
+	}
+	record(c, e)
+	if c.Observer != nil {
+		c.Observer.Observe(e)
+	}
+	level, msg, attrs := e.attrs()
+	logger := c.Logger
+	if logger == nil && level >= slog.LevelWarn {
+		logger = slog.Default()
+	}
+	if logger != nil {
+		logger.LogAttrs(context.Background(), level, msg, attrs...)
+	}
+}
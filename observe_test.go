@@ -0,0 +1,89 @@
+package filever
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"log/slog"
+	"os"
+	"strings"
+	"testing"
+)
+
+// ExampleObserver demonstrates receiving events with an Observer and logging
+// warnings with a slog.Logger.
+func ExampleObserver() {
+	err := os.RemoveAll(observeDist)
+	if err != nil {
+		panic(err)
+	}
+	err = os.MkdirAll(observeDist, 0755)
+	if err != nil {
+		panic(err)
+	}
+	err = os.WriteFile(observeDist+"/index.html", []byte(danglingHTML), 0644)
+	if err != nil {
+		panic(err)
+	}
+
+	counts := map[string]int{}
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{
+		Level: slog.LevelWarn,
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.TimeKey {
+				return slog.Attr{}
+			}
+			return a
+		},
+	}))
+	c := &Config{Src: dummySrc, Dist: observeDist, Logger: logger, Observer: ObserverFunc(func(e Event) {
+		counts[fmt.Sprintf("%T", e)]++
+	})}
+	err = VersionReplace(c)
+	if err != nil {
+		panic(err)
+	}
+	for _, k := range sortedKeys(counts) {
+		fmt.Println(k, counts[k])
+	}
+
+	// Output:
+	// level=WARN msg="reference unresolved" path=test/observe/index.html line=2 column=14 ref="./missing~fv=00000000.js"
+	// filever.FileCopied 4
+	// filever.FileHashed 4
+	// filever.FileRewritten 4
+	// filever.ReferenceUnresolved 1
+}
+
+// TestDefaultLogger tests that, with the default Config, a dangling reference
+// is logged to slog.Default().
+func TestDefaultLogger(t *testing.T) {
+	err := os.RemoveAll(defaultLoggerDist)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.MkdirAll(defaultLoggerDist, 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.WriteFile(defaultLoggerDist+"/index.html", []byte(danglingHTML), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	defer log.SetOutput(log.Writer()) // slog.SetDefault redirects package log.
+	defer log.SetFlags(log.Flags())
+	defer slog.SetDefault(slog.Default())
+	slog.SetDefault(slog.New(slog.NewTextHandler(&buf, nil)))
+	err = VersionReplace(&Config{Src: dummySrc, Dist: defaultLoggerDist})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), `level=WARN msg="reference unresolved"`) || !strings.Contains(buf.String(), "missing~fv=00000000.js") {
+		t.Errorf("dangling reference not logged: %q", buf.String())
+	}
+	if strings.Contains(buf.String(), "level=DEBUG") || strings.Contains(buf.String(), "file hashed") {
+		t.Errorf("non-warnings logged: %q", buf.String())
+	}
+}
@@ -0,0 +1,153 @@
+package filever
+
+import (
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// URLPrefix maps a URL prefix of references to a directory in c.Dist, e.g.
+// references `/static/js/app~fv=4mIbJJPq.js` with Prefix `/static/` and an
+// empty Dir are to `js/app.js`, and references
+// `https://cdn.example.com/assets/app~fv=4mIbJJPq.js` with Prefix
+// `https://cdn.example.com/assets/` and Dir `assets` are to `assets/app.js`.  A
+// Prefix with a scheme also matches the scheme relative form, e.g.
+// `//cdn.example.com/assets/`.
+//
+//	Prefix - URL prefix, e.g. `/static/` or `https://cdn.example.com/assets/`.
+//	Dir    - Directory, slash separated and relative to c.Dist.  Empty for c.Dist.
+type URLPrefix struct {
+	Prefix string
+	Dir    string
+}
+
+// cut returns the rest of ref after p.Prefix, and whether ref has the prefix.
+func (p URLPrefix) cut(ref string) (rest string, ok bool) {
+	if rest, ok = strings.CutPrefix(ref, p.Prefix); ok {
+		return rest, true
+	}
+	if _, schemeRel, found := strings.Cut(p.Prefix, "://"); found {
+		return strings.CutPrefix(ref, "//"+schemeRel)
+	}
+	return "", false
+}
+
+// Reference grammar.  A reference to a versioned file in a source file is a
+// URL, or URL path, matched by FileVerPathReg:
+//
+//	ref      = [ [ scheme "://" host [ ":" port ] ] *pchar "/" ] *nchar delim version *ext [ "?" *qchar ] [ "#" *qchar ]
+//	pchar    = nchar / "." / "/"
+//	nchar    = ALPHA / DIGIT / "-" / "_" / "~" / "@" / "!" / "$" / "+" / pct-encoded / non-ASCII
+//	version  = *( ALPHA / DIGIT / "-" / "_" )
+//	ext      = "." 1*( ALPHA / DIGIT / "-" / "_" )
+//	qchar    = any character except whitespace, quotes, parentheses, "<", ">", "`", "\" and "#"
+//
+// pct-encoded is `%` followed by two hex digits, e.g. `%20` for a space, and
+// non-ASCII is any non-ASCII character except separators (e.g. U+00A0) and
+// quotes (e.g. `“`).  Paths may have dots in directory names (`v1.2/`), `@`
+// scopes (`@scope/pkg/`) and non-ASCII names (`é/`).  Like the "midVer" file
+// name, the file name has no dot before the version.
+//
+// References are delimited by characters outside of pchar, e.g. quotes,
+// parentheses and whitespace, so references in HTML attributes, JavaScript
+// strings, CSS `url()` and `srcset` are matched without their delimiters.
+// Although valid in URL paths, `&`, `'`, `(`, `)`, `*`, `,`, `;`, `=` and `:`
+// (except after scheme) also delimit references since they delimit them in
+// source files, e.g. `src=app~fv=4mIbJJPq.js`.  Literal spaces in a path end
+// the reference; use `%20`.
+//
+// The query and fragment are matched so that they are preserved, and are
+// ignored when resolving the reference.  See resolveRef().
+var (
+	refSchemeReg = `(?:[A-Za-z][0-9A-Za-z+.\-]*://[0-9A-Za-z.\-]+(?::[0-9]+)?)?`
+	refNameReg   = `(?:[0-9A-Za-z\-_~@!$+]|%[0-9A-Fa-f]{2}|[^\x00-\x7F\p{Z}\p{Pi}\p{Pf}])`
+	refExtReg    = `(?:\.[0-9A-Za-z_\-]+)*`
+	refQueryReg  = `(?:\?[^\s"'()\x3c\x3e\x60\\#]*)?(?:#[^\s"'()\x3c\x3e\x60\\#]*)?`
+
+	// refDirReg matches the scheme, host and directory, including the start
+	// path, e.g. `../e/`, of a reference.
+	refDirReg = `(?:` + refSchemeReg + `(?:` + refNameReg + `|[./])*/)?`
+)
+
+// resolveRef returns the bare path, relative to c.Dist, of the versioned file
+// referenced by ref from the file from, which is slash separated and relative
+// to c.Dist.  ref may be versioned and have a query and fragment.  References
+// are resolved like URLs:
+//
+//   - References with the prefix of one of prefixes are in its directory.
+//     The first matching prefix is used.
+//   - A leading `/` is c.Dist, e.g. `/subdir/test_3~fv=4mIbJJPq.js` is
+//     `subdir/test_3.js`.
+//   - Otherwise, the reference is relative to the directory of from, e.g.
+//     `./test_3~fv=4mIbJJPq.js` and `test_3~fv=4mIbJJPq.js` from
+//     `subdir/test_4.js` are `subdir/test_3.js`.
+//
+// References relative to c.Dist regardless of from, the former convention,
+// still resolve: a reference resolving outside of c.Dist, e.g.
+// `../subdir/test_3~fv=4mIbJJPq.js` from `test_1.js`, or a reference without
+// start path or with start path `./` not resolving to a file known to known,
+// e.g. `./test_2~fv=4mIbJJPq.js` from `subdir/test_3.js`, is resolved relative
+// to c.Dist.
+func resolveRef(from, ref string, prefixes []URLPrefix, known func(bare string) bool) (bare string) {
+	r := VerAnySizeRegexC.ReplaceAllString(ref, "")
+	if i := strings.IndexAny(r, "?#"); i != -1 {
+		r = r[:i]
+	}
+	for _, p := range prefixes {
+		if rest, ok := p.cut(r); ok {
+			return path.Join(p.Dir, rest)
+		}
+	}
+	if strings.HasPrefix(r, "/") {
+		return path.Clean(r[1:])
+	}
+	root := r[len(startPathRegC.FindString(r)):] // Relative to c.Dist.
+	bare = path.Join(path.Dir(from), r)
+	if bare == ".." || strings.HasPrefix(bare, "../") {
+		return root
+	}
+	if !strings.HasPrefix(r, "../") && !known(bare) && known(root) {
+		return root
+	}
+	return bare
+}
+
+// currentRef returns the matched reference ref, in the file at from, relative
+// to pwd, with the current version in c.Info.PV.  The rest of the reference,
+// e.g. its start path, URL prefix, query and fragment, is preserved.  If the
+// reference is dangling, the version is dummied.  If ref has no Delim, e.g.
+// when matched by a custom c.SrcReg, it is not a reference and is returned
+// unchanged with ok false.  Likewise for a reference matched by the literal
+// matcher, by file name, that doesn't resolve to a versioned file.
+func currentRef(c *Config, from, ref string) (current string, dummied, ok bool) {
+	loc := VerAnySizeRegexC.FindStringIndex(ref)
+	if loc == nil {
+		return ref, false, false
+	}
+	v, versioned := c.Info.PV[resolveRef(distRel(c, from), ref, c.URLPrefixes, c.isVersioned)]
+	if _, literal := c.refs.(*literalMatcher); literal && !versioned {
+		return ref, false, false
+	}
+	if len(v) < VersionSize {
+		v = DummyVersion()
+		dummied = true
+	}
+	return ref[:loc[0]] + Delim + v + ref[loc[1]:], dummied, true
+}
+
+// isVersioned reports whether the bare path, relative to c.Dist, is in
+// c.Info.PV.
+func (c *Config) isVersioned(bare string) bool {
+	_, ok := c.Info.PV[bare]
+	return ok
+}
+
+// distRel returns name, relative to pwd, slash separated and relative to
+// c.Dist.
+func distRel(c *Config, name string) string {
+	rel, err := filepath.Rel(c.Dist, name)
+	if err != nil {
+		return filepath.ToSlash(name)
+	}
+	return filepath.ToSlash(rel)
+}
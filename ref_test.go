@@ -0,0 +1,229 @@
+package filever
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+// ExampleReplace_relative demonstrates references relative to the referencing
+// file, to same named files in different directories, and a reference relative
+// to dist, the former convention.
+func ExampleReplace_relative() {
+	for _, d := range []string{relativeSrc, relativeDist} {
+		err := os.RemoveAll(d)
+		if err != nil {
+			panic(err)
+		}
+	}
+	for name, content := range map[string]string{
+		"a~fv=00000000.js":     "// Root a.\n",
+		"r~fv=00000000.js":     "// Root r.\n",
+		"sub/a~fv=00000000.js": "// Sub a.\n",
+		"sub/b~fv=00000000.js": "import './a~fv=00000000.js';\nimport '../a~fv=00000000.js';\nimport '/sub/a~fv=00000000.js';\nimport 'a~fv=00000000.js';\nimport './r~fv=00000000.js';\n",
+	} {
+		name = filepath.Join(relativeSrc, name)
+		err := os.MkdirAll(filepath.Dir(name), 0755)
+		if err != nil {
+			panic(err)
+		}
+		err = os.WriteFile(name, []byte(content), 0644)
+		if err != nil {
+			panic(err)
+		}
+	}
+	err := os.MkdirAll(relativeDist, 0755)
+	if err != nil {
+		panic(err)
+	}
+
+	c := &Config{Src: relativeSrc, Dist: relativeDist}
+	err = VersionReplace(c)
+	if err != nil {
+		panic(err)
+	}
+	fmt.Println(c.Info.PV["a.js"], c.Info.PV["sub/a.js"])
+	fv, _ := genFileVer("sub/b.js", c.Info.PV["sub/b.js"], c)
+	b, err := os.ReadFile(filepath.Join(relativeDist, fv))
+	if err != nil {
+		panic(err)
+	}
+	fmt.Print(string(b))
+
+	// Output:
+	// byGxKwNO 2pndUQ-4
+	// import './a~fv=2pndUQ-4.js';
+	// import '../a~fv=byGxKwNO.js';
+	// import '/sub/a~fv=2pndUQ-4.js';
+	// import 'a~fv=2pndUQ-4.js';
+	// import './r~fv=fUfgSgNW.js';
+}
+
+// ExampleURLPrefix demonstrates references with URL prefixes, queries and
+// fragments.
+func ExampleURLPrefix() {
+	for _, d := range []string{prefixSrc, prefixDist} {
+		err := os.RemoveAll(d)
+		if err != nil {
+			panic(err)
+		}
+		err = os.MkdirAll(d, 0755)
+		if err != nil {
+			panic(err)
+		}
+	}
+	err := os.MkdirAll(prefixSrc+"/js", 0755)
+	if err != nil {
+		panic(err)
+	}
+	err = os.WriteFile(prefixSrc+"/js/app~fv=00000000.js", []byte("// App.\n"), 0644)
+	if err != nil {
+		panic(err)
+	}
+	err = os.WriteFile(prefixDist+"/index.html", []byte(`<script src="/static/app~fv=00000000.js?v=1#main"></script>
+<script src="https://cdn.example.com/assets/js/app~fv=00000000.js"></script>
+<script src="//cdn.example.com/assets/js/app~fv=00000000.js"></script>
+`), 0644)
+	if err != nil {
+		panic(err)
+	}
+
+	c := &Config{Src: prefixSrc, Dist: prefixDist, URLPrefixes: []URLPrefix{
+		{Prefix: "/static/", Dir: "js"},
+		{Prefix: "https://cdn.example.com/assets/"},
+	}}
+	err = VersionReplace(c)
+	if err != nil {
+		panic(err)
+	}
+	b, err := os.ReadFile(prefixDist + "/index.html")
+	if err != nil {
+		panic(err)
+	}
+	fmt.Print(string(b))
+
+	// Output:
+	// <script src="/static/app~fv=l1E3Agye.js?v=1#main"></script>
+	// <script src="https://cdn.example.com/assets/js/app~fv=l1E3Agye.js"></script>
+	// <script src="//cdn.example.com/assets/js/app~fv=l1E3Agye.js"></script>
+}
+
+// ExampleFileVerPathReg_grammar demonstrates the reference grammar.  See
+// FileVerPathReg.
+func ExampleFileVerPathReg_grammar() {
+	ts := `<script src="v1.2/app~fv=00000000.min.js"></script>
+<link href='/@scope/pkg/style~fv=00000000.css?v=1#dark'>
+.a{background:url(./img/my%20logo~fv=00000000.png)}
+<img srcset="é/photo~fv=00000000.jpg 1x, https://cdn.example.com:8080/photo~fv=00000000.jpg 2x">
+import x from "./app~fv=00000000.js".
+src=app~fv=00000000.js (see ../app~fv=00000000.js)
+`
+	for _, m := range regexp.MustCompile(FileVerPathReg).FindAllString(ts, -1) {
+		fmt.Println(m)
+	}
+
+	// Output:
+	// v1.2/app~fv=00000000.min.js
+	// /@scope/pkg/style~fv=00000000.css?v=1#dark
+	// ./img/my%20logo~fv=00000000.png
+	// é/photo~fv=00000000.jpg
+	// https://cdn.example.com:8080/photo~fv=00000000.jpg
+	// ./app~fv=00000000.js
+	// app~fv=00000000.js
+	// ../app~fv=00000000.js
+}
+
+// Anchored parts of the reference grammar for fuzzing.
+var (
+	fuzzDirReg     = regexp.MustCompile(`^` + refDirReg + `$`)
+	fuzzBareReg    = regexp.MustCompile(`^` + refNameReg + `+$`)
+	fuzzVersionReg = regexp.MustCompile(`^[0-9A-Za-z_\-]*$`)
+	fuzzExtReg     = regexp.MustCompile(`^` + refExtReg + `$`)
+	fuzzQueryReg   = regexp.MustCompile(`^` + refQueryReg + `$`)
+)
+
+// FuzzFileVerPathReg checks that references built from the grammar are
+// matched whole, without their delimiters, and that PathParts.Populate parses
+// the matched reference back into its parts.
+func FuzzFileVerPathReg(f *testing.F) {
+	f.Add("", "app", "4mIbJJPq", ".min.js", "")
+	f.Add("v1.2/", "app", "4mIbJJPq", ".js", "")
+	f.Add("/@scope/pkg/", "index", "4mIbJJPq", ".js", "?v=1&b=2#top")
+	f.Add("../a%20b/", "my%20app", "4mIbJJPqX", ".css", "#x")
+	f.Add("https://cdn.example.com:8080/é/", "日本", "", ".png", "?")
+	f.Add("//cdn.example.com/", "a~b", "4mIbJJPq", "", "")
+	ref := regexp.MustCompile(FileVerPathReg)
+	f.Fuzz(func(t *testing.T, dir, bare, version, ext, query string) {
+		if !utf8.ValidString(dir+bare+version+ext+query) ||
+			!fuzzDirReg.MatchString(dir) || !fuzzBareReg.MatchString(bare) ||
+			!fuzzVersionReg.MatchString(version) || !fuzzExtReg.MatchString(ext) ||
+			!fuzzQueryReg.MatchString(query) {
+			t.Skip()
+		}
+		r := dir + bare + Delim + version + ext
+		for _, delims := range [][2]string{{`"`, `"`}, {`'`, `'`}, {"url(", ")"}, {" ", " 2x,"}, {"=", ">"}, {"`", "`"}} {
+			ts := delims[0] + r + query + delims[1]
+			if m := ref.FindString(ts); m != r+query {
+				t.Fatalf("FindString(%q) = %q, want %q", ts, m, r+query)
+			}
+		}
+
+		p := Populated(r)
+		if p.Dir != dir || p.Bare != bare || p.Version != version || p.Ext != ext || p.BarePath != dir+bare+ext {
+			t.Fatalf("Populated(%q) = %+v", r, p)
+		}
+	})
+}
+
+// FuzzPathPartsPopulate checks that any text matched by FileVerPathReg, less
+// the query and fragment, is parsed by PathParts.Populate into a versioned
+// file that regenerates the reference.
+func FuzzPathPartsPopulate(f *testing.F) {
+	f.Add(`<a href="v1.2/app~fv=4mIbJJPq.min.js?x">`)
+	f.Add(`url(@scope/é/my%20app~fv=4mIbJJPqX.css#a)`)
+	f.Add(`app~fv=.js. ~fv=4mIbJJPq ~fv=~fv=4mIbJJPq.a..b`)
+	ref := regexp.MustCompile(FileVerPathReg)
+	f.Fuzz(func(t *testing.T, ts string) {
+		for _, m := range ref.FindAllString(ts, -1) {
+			r, _, _ := strings.Cut(m, "?")
+			r, _, _ = strings.Cut(r, "#")
+			p := Populated(r)
+			if !strings.Contains(p.File, Delim) || p.Dir+p.Bare+Delim+p.Version+p.Ext != r {
+				t.Fatalf("Populated(%q) = %+v", r, p)
+			}
+		}
+	})
+}
+
+// TestSrcReg tests that matches of a custom Config.SrcReg without a version,
+// e.g. `Comments`, are left unchanged and not counted.
+func TestSrcReg(t *testing.T) {
+	err := os.RemoveAll(srcRegDist)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.MkdirAll(srcRegDist, 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := &Config{Src: dummySrc, Dist: srcRegDist, SrcReg: regexp.MustCompile(`Comments|` + FileVerPathReg)}
+	err = VersionReplace(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Info.TotalSourceReplaces != 15 {
+		t.Errorf("TotalSourceReplaces = %d, want 15", c.Info.TotalSourceReplaces)
+	}
+	fv, _ := genFileVer("test_1.js", c.Info.PV["test_1.js"], c)
+	b, err := os.ReadFile(filepath.Join(srcRegDist, fv))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(b), "Comments referring to './test_1~fv="+c.Info.PV["test_1.js"]+".js'") {
+		t.Errorf("%s not replaced:\n%s", fv, b)
+	}
+}
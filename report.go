@@ -0,0 +1,101 @@
+package filever
+
+import (
+	"context"
+	"sort"
+	"time"
+)
+
+// Report is the report of a run, returned by Run() and given in Cycle by
+// Watch(), e.g. for CI dashboards and PR comments.  Report is JSON
+// serializable.  Paths are relative to pwd unless noted.
+//
+//	Files     - Versioned files, sorted by bare path.
+//	Rewritten - Files updated by Replace(), with the number of references replaced.
+//	Unchanged - Files scanned by Replace() whose references were already current.
+//	Skipped   - Files skipped by Replace() because they are not text.
+//	Deleted   - Versioned files deleted, e.g. previous versions.
+//	Phases    - Duration of each phase of the run, in order.
+type Report struct {
+	Files     []FileReport
+	Rewritten []RewriteReport
+	Unchanged []string
+	Skipped   []string
+	Deleted   []string
+	Phases    []Phase
+}
+
+// FileReport is the report of a versioned file.
+//
+//	BarePath   - Bare path, relative to c.Dist, e.g. `app.min.js`.
+//	Digest     - Canonical digest of the file.  See Canonical().
+//	OldVersion - Version in c.Dist before the run.  Empty if the file is new.
+//	Version    - Version after the run.
+//	Size       - Bytes hashed.
+type FileReport struct {
+	BarePath   string
+	Digest     string
+	OldVersion string
+	Version    string
+	Size       int
+}
+
+// RewriteReport is the report of a file updated by Replace().
+//
+//	Path     - File, relative to pwd.
+//	Replaces - Number of references replaced.
+type RewriteReport struct {
+	Path     string
+	Replaces int
+}
+
+// Phase is the duration of a phase of a run.
+//
+//	Name     - One of `transform`, `version`, `replace`, `compress`, `manifest`, or `history`.
+//	Duration - Duration of the phase, in nanoseconds in JSON.
+type Phase struct {
+	Name     string
+	Duration time.Duration
+}
+
+// Run is VersionReplaceContext() returning the report of the run.  On error,
+// the report up to the error is returned.
+func Run(ctx context.Context, c *Config) (r *Report, err error) {
+	r = startReport(c)
+	err = VersionReplaceContext(ctx, c)
+	endReport(c)
+	return r, err
+}
+
+// startReport starts recording the report of a run in c.
+func startReport(c *Config) *Report {
+	c.report = new(Report)
+	return c.report
+}
+
+// endReport stops recording the report of c's run and sorts it.
+func endReport(c *Config) {
+	sort.Slice(c.report.Files, func(i, j int) bool { return c.report.Files[i].BarePath < c.report.Files[j].BarePath })
+	c.report = nil
+}
+
+// phase records, if c is recording a report, the duration since start of the
+// phase name.
+func phase(c *Config, name string, start time.Time) {
+	if c.report != nil {
+		c.report.Phases = append(c.report.Phases, Phase{Name: name, Duration: time.Since(start)})
+	}
+}
+
+// record records e, if c is recording a report.  c may be nil.
+func record(c *Config, e Event) {
+	if c == nil || c.report == nil {
+		return
+	}
+	switch e := e.(type) {
+	case VersionDeleted:
+		c.report.Deleted = append(c.report.Deleted, e.Path)
+	case FileRewritten:
+		c.report.Rewritten = append(c.report.Rewritten, RewriteReport{Path: e.Path, Replaces: e.Replaces})
+	}
+}
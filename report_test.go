@@ -0,0 +1,103 @@
+package filever
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// ExampleRun demonstrates the report of a run.  Durations are zeroed for the
+// example.
+func ExampleRun() {
+	err := os.RemoveAll(reportDist)
+	if err != nil {
+		panic(err)
+	}
+	err = os.MkdirAll(reportDist, 0755)
+	if err != nil {
+		panic(err)
+	}
+
+	c := &Config{Src: dummySrc, Dist: reportDist}
+	r, err := Run(context.Background(), c)
+	if err != nil {
+		panic(err)
+	}
+	fmt.Println(len(r.Files), len(r.Rewritten), len(r.Phases))
+
+	r, err = Run(context.Background(), c) // Nothing changed.
+	if err != nil {
+		panic(err)
+	}
+	for i := range r.Phases {
+		r.Phases[i].Duration = 0
+	}
+	b, err := json.MarshalIndent(r, "", "\t")
+	if err != nil {
+		panic(err)
+	}
+	fmt.Println(string(b))
+
+	// Output:
+	// 4 4 4
+	// {
+	// 	"Files": [
+	// 		{
+	// 			"BarePath": "subdir/test_3.js",
+	// 			"Digest": "_X83uO__Pvm-c0TdPS86WhdiRpTCeu1Pgvs-b3N_klU",
+	// 			"OldVersion": "_X83uO__",
+	// 			"Version": "_X83uO__",
+	// 			"Size": 159
+	// 		},
+	// 		{
+	// 			"BarePath": "subdir/test_4.js",
+	// 			"Digest": "GJIrg6k154bqRtCNCtx3BWYcOvLqrkOisJM6l47mz28",
+	// 			"OldVersion": "GJIrg6k1",
+	// 			"Version": "GJIrg6k1",
+	// 			"Size": 425
+	// 		},
+	// 		{
+	// 			"BarePath": "test_1.js",
+	// 			"Digest": "vPCb4GVOidk9y3TYHaTcZUE1ALtmFh71jxanQvpScYQ",
+	// 			"OldVersion": "vPCb4GVO",
+	// 			"Version": "vPCb4GVO",
+	// 			"Size": 305
+	// 		},
+	// 		{
+	// 			"BarePath": "test_2.js",
+	// 			"Digest": "BOl7h9TMSdrmXeksz_Qzc61DmDCQXPwSNVblfVXPfJc",
+	// 			"OldVersion": "BOl7h9TM",
+	// 			"Version": "BOl7h9TM",
+	// 			"Size": 304
+	// 		}
+	// 	],
+	// 	"Rewritten": null,
+	// 	"Unchanged": [
+	// 		"test/report/subdir/test_3~fv=_X83uO__.js",
+	// 		"test/report/subdir/test_4~fv=GJIrg6k1.js",
+	// 		"test/report/test_1~fv=vPCb4GVO.js",
+	// 		"test/report/test_2~fv=BOl7h9TM.js"
+	// 	],
+	// 	"Skipped": null,
+	// 	"Deleted": null,
+	// 	"Phases": [
+	// 		{
+	// 			"Name": "transform",
+	// 			"Duration": 0
+	// 		},
+	// 		{
+	// 			"Name": "version",
+	// 			"Duration": 0
+	// 		},
+	// 		{
+	// 			"Name": "replace",
+	// 			"Duration": 0
+	// 		},
+	// 		{
+	// 			"Name": "compress",
+	// 			"Duration": 0
+	// 		}
+	// 	]
+	// }
+}
@@ -0,0 +1,143 @@
+package filever
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"sort"
+	"time"
+)
+
+// Retention is the policy for retaining previous versions of files in `dist`.
+// When Config.Retention is set, Version() does not delete previous versions
+// and Prune() must be called to remove them.  A previous version is retained
+// if any rule retains it.  The current version is always retained.
+//
+// Retaining previous versions allows clients that have already loaded an old
+// page to still load the old versions during a rolling deploy.
+//
+//	KeepLast      - Keep the last N versions, by modification time, including the current version.
+//	KeepNewer     - Keep versions modified less than duration ago.
+//	KeepManifests - Keep versions referenced in the last K manifests.  Requires Config.Manifest.
+type Retention struct {
+	KeepLast      int
+	KeepNewer     time.Duration
+	KeepManifests int
+}
+
+// versionedFile is a versioned file in `dist` considered by Prune.
+type versionedFile struct {
+	path    string // Relative to pwd.
+	version string
+	modTime time.Time
+}
+
+// Prune removes previous versions of files from c.Dist according to
+// c.Retention.  If c.Retention is nil, all previous versions are removed. The
+// current version is c.Info.PV's version if c.Info is set, otherwise the most
+// recently modified version.  Returns removed versioned files, relative to pwd.
+//
+// Prune also removes the copies of manifests in ManifestsDir (see
+// WriteManifest()) except the last c.Retention.KeepManifests, and at least the
+// last.
+func Prune(c *Config) (pruned []string, err error) {
+	return PruneContext(context.Background(), c)
+}
+
+// PruneContext is Prune() stopping, with ctx.Err(), when ctx is done.
+// Cancellation is checked between versioned files.
+func PruneContext(ctx context.Context, c *Config) (pruned []string, err error) {
+	r := c.Retention
+	if r == nil {
+		r = new(Retention)
+	}
+
+	// Group versioned files in dist by bare path.
+	groups := map[string][]versionedFile{}
+	var walk = func(path, rel string, d fs.DirEntry) error {
+		p := Populated(rel)
+		if p.Version == "" || isCompressedSibling(rel) { // Siblings are pruned with their versioned file.
+			return nil
+		}
+		fi, err := d.Info()
+		if err != nil {
+			return err
+		}
+		groups[p.BarePath] = append(groups[p.BarePath], versionedFile{path, p.Version, fi.ModTime()})
+		return nil
+	}
+	f, err := newFilter(c, c.Dist)
+	if err != nil {
+		return nil, err
+	}
+	err = walkFiles(ctx, c.Dist, f, walk)
+	if err != nil {
+		return nil, err
+	}
+
+	copies, err := manifestCopies(c.Dist)
+	if err != nil {
+		return nil, err
+	}
+	var manifests []*Manifest
+	for i := 0; i < len(copies) && i < r.KeepManifests; i++ {
+		manifests = append(manifests, copies[i].m)
+	}
+
+	now := time.Now()
+	for _, bare := range sortedKeys(groups) {
+		files := groups[bare]
+		sort.Slice(files, func(i, j int) bool { return files[i].modTime.After(files[j].modTime) })
+
+		current := files[0].version
+		if c.Info != nil {
+			if v, ok := c.Info.PV[bare]; ok {
+				current = v
+			}
+		}
+
+		for i, f := range files {
+			if err := ctx.Err(); err != nil {
+				return pruned, err
+			}
+			if f.version == current ||
+				i < r.KeepLast ||
+				(r.KeepNewer > 0 && now.Sub(f.modTime) < r.KeepNewer) ||
+				inManifests(manifests, bare, f.version) {
+				continue
+			}
+			err = os.Remove(f.path)
+			if err != nil {
+				return pruned, err
+			}
+			err = removeCompressedSiblings(f.path)
+			if err != nil {
+				return pruned, err
+			}
+			emit(c, VersionDeleted{Path: f.path})
+			pruned = append(pruned, f.path)
+		}
+	}
+
+	// Manifest copies are removed once no longer needed by the versions above.
+	for i := max(r.KeepManifests, 1); i < len(copies); i++ {
+		if err := ctx.Err(); err != nil {
+			return pruned, err
+		}
+		err = os.Remove(copies[i].path)
+		if err != nil {
+			return pruned, fileOpErr("remove", copies[i].path, err)
+		}
+	}
+	return pruned, nil
+}
+
+// inManifests reports whether any manifest references the version of bare.
+func inManifests(ms []*Manifest, bare, version string) bool {
+	for _, m := range ms {
+		if m.PV[bare] == version {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,111 @@
+package filever
+
+import (
+	"fmt"
+	"os"
+	"time"
+)
+
+// writeOldVersion writes a fake previous version of a file, referring to a
+// previous version of another file, into dist with a modification time of age
+// ago.
+func writeOldVersion(dist, fileVer string, age time.Duration) {
+	p := dist + "/" + fileVer
+	err := os.WriteFile(p, []byte("import * as test2 from './test_2~fv=AAAAAAAA.js';"), 0644)
+	if err != nil {
+		panic(err)
+	}
+	t := time.Now().Add(-age)
+	err = os.Chtimes(p, t, t)
+	if err != nil {
+		panic(err)
+	}
+}
+
+// ExamplePrune demonstrates retaining the last two versions of a file.
+func ExamplePrune() {
+	err := os.RemoveAll(retentionDist + "/" + MetaDir)
+	if err != nil {
+		panic(err)
+	}
+	writeOldVersion(retentionDist, "test_1~fv=AAAAAAAA.js", time.Hour)
+	writeOldVersion(retentionDist, "test_1~fv=BBBBBBBB.js", 2*time.Hour)
+
+	c := &Config{Src: dummySrc, Dist: retentionDist, Retention: &Retention{KeepLast: 2}}
+	err = VersionReplace(c)
+	if err != nil {
+		panic(err)
+	}
+
+	pruned, err := Prune(c)
+	if err != nil {
+		panic(err)
+	}
+	fmt.Println(pruned)
+	f, err := ListFilesInPath(retentionDist)
+	if err != nil {
+		panic(err)
+	}
+	fmt.Println(f)
+	PrintFile(retentionDist + "/test_1~fv=AAAAAAAA.js")
+
+	// Output:
+	// [test/retention/test_1~fv=BBBBBBBB.js]
+	// [not_versioned_example.txt test_1~fv=AAAAAAAA.js test_1~fv=vPCb4GVO.js test_2~fv=BOl7h9TM.js]
+	// File test/retention/test_1~fv=AAAAAAAA.js:
+	// ////////////////
+	// import * as test2 from './test_2~fv=AAAAAAAA.js';
+	// ////////////////
+}
+
+// ExamplePrune_manifests demonstrates retaining versions referenced in recent
+// manifests, and pruning older manifests.
+func ExamplePrune_manifests() {
+	err := os.RemoveAll(retentionDist + "/" + MetaDir)
+	if err != nil {
+		panic(err)
+	}
+	writeOldVersion(retentionDist, "test_1~fv=AAAAAAAA.js", time.Hour)
+
+	// Manifest of a previous release referring to the old version.
+	old := &Config{Dist: retentionDist, Info: &Info{PV: map[string]string{"test_1.js": "AAAAAAAA"}}}
+	err = WriteManifest(old)
+	if err != nil {
+		panic(err)
+	}
+
+	c := &Config{Src: dummySrc, Dist: retentionDist, Manifest: true, Retention: &Retention{KeepManifests: 2}}
+	err = VersionReplace(c)
+	if err != nil {
+		panic(err)
+	}
+	pruned, err := Prune(c)
+	if err != nil {
+		panic(err)
+	}
+	fmt.Println(pruned)
+	ms, err := ReadManifests(retentionDist)
+	if err != nil {
+		panic(err)
+	}
+	fmt.Println(len(ms))
+
+	// Only the current manifest is considered, and kept.
+	c.Retention.KeepManifests = 1
+	pruned, err = Prune(c)
+	if err != nil {
+		panic(err)
+	}
+	fmt.Println(pruned)
+	ms, err = ReadManifests(retentionDist)
+	if err != nil {
+		panic(err)
+	}
+	fmt.Println(len(ms), ms[0].PV["test_1.js"])
+
+	// Output:
+	// []
+	// 2
+	// [test/retention/test_1~fv=AAAAAAAA.js]
+	// 1 vPCb4GVO
+}
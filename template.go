@@ -0,0 +1,71 @@
+package filever
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Funcs holds the settings for template functions.  See FuncMap().
+//
+//	Manifest - Current versions.  For an in-memory Config.Info, use NewManifest().
+//	Strict   - If true, functions error on bare paths not in Manifest, so that
+//	             templates fail at render time instead of referring to missing files.
+//	             If false, unknown bare paths are returned unchanged.
+//	BaseURL  - Prefix for `fvURL`, e.g. `https://cdn.example.com/assets`.
+type Funcs struct {
+	Manifest *Manifest
+	Strict   bool
+	BaseURL  string
+}
+
+// FuncMap returns template functions backed by m.  Unknown bare paths are
+// returned unchanged.  See Funcs.FuncMap().
+func FuncMap(m *Manifest) map[string]any {
+	return (&Funcs{Manifest: m}).FuncMap()
+}
+
+// FuncMap returns template functions for `html/template` and `text/template`.
+// The returned map is assignable to both `template.FuncMap` types.
+//
+//	fv          - Pathed FileVer of a bare path, e.g. `{{fv "/e/app.min.js"}}` is `/e/app~fv=4mIbJJPq.min.js`.
+//	fvIntegrity - Subresource Integrity of a bare path.
+//	fvURL       - BaseURL joined with the pathed FileVer of a bare path.
+func (f *Funcs) FuncMap() map[string]any {
+	return map[string]any{
+		"fv":          f.fv,
+		"fvIntegrity": f.fvIntegrity,
+		"fvURL":       f.fvURL,
+	}
+}
+
+// fv returns the pathed FileVer of barePath.  A leading `/` is preserved.
+func (f *Funcs) fv(barePath string) (string, error) {
+	root := strings.HasPrefix(barePath, "/")
+	fv, ok := f.Manifest.FileVer(strings.TrimPrefix(barePath, "/"))
+	if !ok {
+		if f.Strict {
+			return "", fmt.Errorf("fv: %s not in manifest", barePath)
+		}
+		return barePath, nil
+	}
+	if root {
+		fv = "/" + fv
+	}
+	return fv, nil
+}
+
+func (f *Funcs) fvIntegrity(barePath string) (string, error) {
+	i, ok := f.Manifest.Integrity[strings.TrimPrefix(barePath, "/")]
+	if !ok && f.Strict {
+		return "", fmt.Errorf("fvIntegrity: %s not in manifest", barePath)
+	}
+	return i, nil
+}
+
+func (f *Funcs) fvURL(barePath string) (string, error) {
+	fv, err := f.fv(strings.TrimPrefix(barePath, "/"))
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(f.BaseURL, "/") + "/" + fv, nil
+}
@@ -0,0 +1,42 @@
+package filever
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"os"
+)
+
+func ExampleFuncMap() {
+	c := &Config{Src: dummySrc, Dist: dummyDist}
+	err := VersionReplace(c)
+	if err != nil {
+		panic(err)
+	}
+	m, err := NewManifest(c)
+	if err != nil {
+		panic(err)
+	}
+
+	f := &Funcs{Manifest: m, BaseURL: "https://cdn.example.com/assets/"}
+	t := template.Must(template.New("").Funcs(f.FuncMap()).Parse(
+		`<script src="{{fv "/subdir/test_3.js"}}"></script>
+<script src="{{fvURL "test_1.js"}}" integrity="{{fvIntegrity "test_1.js"}}"></script>
+<script src="{{fv "missing.js"}}"></script>
+`))
+	err = t.Execute(os.Stdout, nil)
+	if err != nil {
+		panic(err)
+	}
+
+	// Strict errors on unknown bare paths.
+	f.Strict = true
+	err = t.Execute(new(bytes.Buffer), nil)
+	fmt.Println(err)
+
+	// Output:
+	// <script src="/subdir/test_3~fv=_X83uO__.js"></script>
+	// <script src="https://cdn.example.com/assets/test_1~fv=vPCb4GVO.js" integrity="sha384-k0eH&#43;m8&#43;DbS5n1V8jOrgU0rFBGVPOar29o1dhVZazx&#43;DVE2DHC4C09bjn9/W/0RE"></script>
+	// <script src="missing.js"></script>
+	// template: :3:15: executing "" at <fv "missing.js">: error calling fv: fv: missing.js not in manifest
+}
@@ -0,0 +1,3 @@
+import * as test1 from '../test_1~fv=00000000.js';
+import * as test2 from '../test_2~fv=00000000.js';
+import * as test4 from '../subdir/test_4~fv=00000000.js';
\ No newline at end of file
@@ -0,0 +1,49 @@
+package filever
+
+import (
+	"mime"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/exp/slices"
+)
+
+// DefaultTextExts are the extensions of text files scanned by Replace() when
+// Config.TextExts is nil.
+var DefaultTextExts = []string{".html", ".htm", ".css", ".js", ".mjs", ".cjs", ".jsx", ".ts", ".tsx", ".json", ".map", ".webmanifest", ".svg", ".xml", ".txt", ".md", ".yaml", ".yml", ".sh"}
+
+// isText reports whether the file at path with content b is text, and should
+// be scanned by Replace().  Files with an extension in c.TextExts (default
+// DefaultTextExts) are text.  Files with other extensions with a known MIME
+// type are text if the type is text, e.g. `.csv`, and not if it isn't, e.g.
+// `.png`.  See isTextType().  Files with unknown or no extension are text if
+// their content sniffs as text.
+func isText(c *Config, path string, b []byte) bool {
+	exts := c.TextExts
+	if exts == nil {
+		exts = DefaultTextExts
+	}
+	ext := strings.ToLower(filepath.Ext(path))
+	if slices.Contains(exts, ext) {
+		return true
+	}
+	if ext != "" {
+		if t := mime.TypeByExtension(ext); t != "" {
+			return isTextType(t)
+		}
+	}
+	return isTextType(http.DetectContentType(b))
+}
+
+// isTextType reports whether the MIME type t, e.g. `text/csv; charset=utf-8`,
+// is text: `text/*`, `application/json`, `application/javascript`, `*+json`
+// or `*+xml`.
+func isTextType(t string) bool {
+	t, _, err := mime.ParseMediaType(t)
+	if err != nil {
+		return false
+	}
+	return strings.HasPrefix(t, "text/") || t == "application/json" || t == "application/javascript" ||
+		strings.HasSuffix(t, "+json") || strings.HasSuffix(t, "+xml")
+}
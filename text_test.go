@@ -0,0 +1,75 @@
+package filever
+
+import (
+	"fmt"
+	"os"
+	"testing"
+)
+
+// ExampleReplace_binary demonstrates Replace() skipping binary files, even when
+// they contain a byte sequence matching a reference.
+func ExampleReplace_binary() {
+	for _, d := range []string{binarySrc, binaryDist} {
+		err := os.RemoveAll(d)
+		if err != nil {
+			panic(err)
+		}
+		err = os.MkdirAll(d, 0755)
+		if err != nil {
+			panic(err)
+		}
+	}
+	files := map[string]string{
+		binarySrc + "/app~fv=00000000.js": "console.log('app');",
+		binaryDist + "/index.html":        `<script src="app~fv=00000000.js"></script>`,
+		binaryDist + "/page.tmpl":         `<script src="app~fv=00000000.js"></script>`,
+		binaryDist + "/logo.png":          "\x89PNG\r\n\x1a\n\x00\x00\"app~fv=00000000.js\"",
+	}
+	for name, content := range files {
+		err := os.WriteFile(name, []byte(content), 0644)
+		if err != nil {
+			panic(err)
+		}
+	}
+
+	c := &Config{Src: binarySrc, Dist: binaryDist}
+	err := VersionReplace(c)
+	if err != nil {
+		panic(err)
+	}
+	fmt.Println(c.Info.UpdatedFilePaths)
+	fmt.Println(c.Info.SkippedFilePaths)
+	b, err := os.ReadFile(binaryDist + "/logo.png")
+	if err != nil {
+		panic(err)
+	}
+	fmt.Println(string(b) == files[binaryDist+"/logo.png"])
+
+	// Output:
+	// [test/binary/dist/index.html test/binary/dist/page.tmpl]
+	// [test/binary/dist/logo.png]
+	// true
+}
+
+// TestIsTextType tests text MIME types, e.g. of `.csv` and `.scss`, which are
+// known on most, but not all, systems.  isTextType is tested directly since
+// registering types with mime.AddExtensionType would affect other tests.
+func TestIsTextType(t *testing.T) {
+	for typ, want := range map[string]bool{
+		"text/csv; charset=utf-8":   true,
+		"text/x-scss":               true,
+		"text/plain; charset=utf-8": true,
+		"application/json":          true,
+		"application/javascript":    true,
+		"application/ld+json":       true,
+		"image/svg+xml":             true,
+		"image/png":                 false,
+		"application/octet-stream":  false,
+		"application/pdf":           false,
+		"":                          false,
+	} {
+		if got := isTextType(typ); got != want {
+			t.Errorf("isTextType(%q) = %v, want %v", typ, got, want)
+		}
+	}
+}
@@ -0,0 +1,210 @@
+package filever
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/exp/slices"
+)
+
+// Transformer transforms a src file before hashing, e.g. bundling, minifying,
+// or compiling.  Transformers are run by Transform().
+type Transformer interface {
+	// Match reports whether the Transformer transforms the src file at path,
+	// relative to c.Src.
+	Match(path string) bool
+	// Transform returns the output files for the src file at path, relative to
+	// c.Src, with content b.
+	Transform(path string, b []byte) (outs []Output, err error)
+}
+
+// Output is a file output by a Transformer.
+//
+//	Path      - Path relative to c.Src, e.g. `test_1.min.js`.  Paths without a version are renamed to the dummy form, e.g. `test_1~fv=00000000.min.js`.
+//	Content   - Content of the file.
+//	NoVersion - If true, the file is not versioned and is written to c.Dist at Path instead, e.g. source maps.
+type Output struct {
+	Path      string
+	Content   []byte
+	NoVersion bool
+}
+
+// Transform runs c.Transformers on the non-versioned files in c.Src, not
+// skipped by c.Include, c.Exclude, or c.Src's IgnoreFile, and writes the output
+// files.  Versioned outputs are written into c.Src in the dummy
+// form, so that bundling, renaming to the dummy form and versioning are one
+// step.  If c.SrcFiles is set, versioned outputs are added to c.SrcFiles.
+// Version() calls Transform.
+//
+// Each src file is given to the first Transformer that matches it.
+func Transform(c *Config) (err error) {
+	return transform(context.Background(), c)
+}
+
+// transform is Transform() stopping, with ctx.Err(), when ctx is done.
+func transform(ctx context.Context, c *Config) (err error) {
+	if len(c.Transformers) == 0 {
+		return nil
+	}
+
+	f, err := newFilter(c, c.Src)
+	if err != nil {
+		return err
+	}
+	var files []string
+	err = walkFiles(ctx, c.Src, f, func(_, rel string, d fs.DirEntry) error {
+		if !strings.Contains(d.Name(), Delim) {
+			files = append(files, rel)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, f := range files {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		t := matchTransformer(c, f)
+		if t == nil {
+			continue
+		}
+		_, err = transformFile(c, t, f)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// transformFile runs t on the src file f, relative to c.Src, and writes the
+// output files.  Returns the versioned outputs, relative to c.Src.
+func transformFile(c *Config, t Transformer, f string) (versioned []string, err error) {
+	b, err := os.ReadFile(filepath.Join(c.Src, filepath.FromSlash(f)))
+	if err != nil {
+		return nil, err
+	}
+	outs, err := t.Transform(f, b)
+	if err != nil {
+		return nil, fmt.Errorf("Transform %s: %w", f, err)
+	}
+
+	for _, o := range outs {
+		dir := c.Src
+		p := o.Path
+		if o.NoVersion {
+			dir = c.Dist
+		} else if Populated(path.Base(p)).Version == "" {
+			p, _ = genFileVer(p, "", c)
+		}
+		name := filepath.Join(dir, filepath.FromSlash(p))
+		err = os.MkdirAll(filepath.Dir(name), 0755)
+		if err != nil {
+			return nil, err
+		}
+		err = os.WriteFile(name, o.Content, 0644)
+		if err != nil {
+			return nil, err
+		}
+		if o.NoVersion {
+			continue
+		}
+
+		rel := filepath.FromSlash(p)
+		versioned = append(versioned, rel)
+		if c.SrcFiles != nil && !slices.Contains(c.SrcFiles, rel) {
+			c.SrcFiles = append(c.SrcFiles, rel)
+		}
+	}
+	return versioned, nil
+}
+
+// matchTransformer returns the first of c.Transformers matching the src file
+// at path, relative to c.Src, or nil.
+func matchTransformer(c *Config, path string) Transformer {
+	for _, t := range c.Transformers {
+		if t.Match(path) {
+			return t
+		}
+	}
+	return nil
+}
+
+// CommandTransformer is a Transformer running an external command, e.g.
+// esbuild, on src files matching Glob.  The src file's content is given on
+// stdin and the command's stdout is the output.  `{path}` in Command is
+// replaced with the src file's path relative to c.Src.
+//
+//	Glob    - path.Match pattern, e.g. `*.js`.  Without a `/`, Glob is matched against the base name.
+//	Command - Command and arguments, e.g. `esbuild {path} --bundle --minify`.
+//	Dir     - Working directory of the command, normally c.Src.  Default: pwd.
+//	Out     - Output path.  `{dir}`, `{name}` (base name without extensions) and `{ext}` are replaced.  Default: the src file's path.
+type CommandTransformer struct {
+	Glob    string
+	Command []string
+	Dir     string
+	Out     string
+}
+
+// Match implements Transformer.
+func (t *CommandTransformer) Match(p string) bool {
+	return globMatch(t.Glob, p)
+}
+
+// Transform implements Transformer.
+func (t *CommandTransformer) Transform(p string, b []byte) (outs []Output, err error) {
+	if len(t.Command) == 0 {
+		return nil, &ConfigError{Field: "CommandTransformer.Command", Msg: "must be set"}
+	}
+	args := make([]string, len(t.Command))
+	for i, a := range t.Command {
+		args[i] = strings.ReplaceAll(a, "{path}", p)
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Dir = t.Dir
+	cmd.Stdin = bytes.NewReader(b)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	err = cmd.Run()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w: %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+
+	return []Output{{Path: outPath(t.Out, p), Content: stdout.Bytes()}}, nil
+}
+
+// globMatch reports whether path p matches the path.Match pattern glob.
+// Without a `/`, glob is matched against the base name.
+func globMatch(glob, p string) bool {
+	if !strings.Contains(glob, "/") {
+		p = path.Base(p)
+	}
+	ok, _ := path.Match(glob, p)
+	return ok
+}
+
+// outPath returns the output path for the src file at p from out, with
+// `{dir}`, `{name}` (base name without extensions) and `{ext}` replaced.  If
+// out is empty, p is returned.
+func outPath(out, p string) string {
+	if out == "" {
+		return p
+	}
+	dir, base := path.Split(p)
+	name, ext, _ := strings.Cut(base, ".")
+	if ext != "" {
+		ext = "." + ext
+	}
+	out = strings.NewReplacer("{dir}", dir, "{name}", name, "{ext}", ext).Replace(out)
+	return strings.TrimPrefix(path.Clean(out), "/")
+}
@@ -0,0 +1,67 @@
+//go:build esbuild
+
+package filever
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/evanw/esbuild/pkg/api"
+)
+
+// EsbuildTransformer is a Transformer running esbuild in-process, using
+// esbuild's Go API, on src files matching Glob.  Source maps are output as not
+// versioned.  Requires build tag `esbuild`.
+//
+//	Glob    - path.Match pattern, e.g. `*.js`.  Without a `/`, Glob is matched against the base name.
+//	Src     - c.Src, for resolving imports.
+//	Out     - Output path.  See CommandTransformer.Out.  Default: the src file's path.
+//	Options - esbuild options, e.g. Bundle, MinifyWhitespace, Sourcemap.  EntryPoints, Outfile and Write are set by Transform.
+type EsbuildTransformer struct {
+	Glob    string
+	Src     string
+	Out     string
+	Options api.BuildOptions
+}
+
+// Match implements Transformer.
+func (t *EsbuildTransformer) Match(p string) bool {
+	return globMatch(t.Glob, p)
+}
+
+// Transform implements Transformer.
+func (t *EsbuildTransformer) Transform(p string, b []byte) (outs []Output, err error) {
+	src, err := filepath.Abs(t.Src)
+	if err != nil {
+		return nil, err
+	}
+	opts := t.Options
+	opts.EntryPoints = []string{filepath.Join(src, filepath.FromSlash(p))}
+	opts.Outfile = filepath.Join(src, filepath.FromSlash(outPath(t.Out, p)))
+	opts.Write = false
+
+	r := api.Build(opts)
+	if len(r.Errors) > 0 {
+		var msgs []string
+		for _, m := range r.Errors {
+			if m.Location != nil {
+				msgs = append(msgs, fmt.Sprintf("%s:%d:%d: %s", m.Location.File, m.Location.Line, m.Location.Column, m.Text))
+				continue
+			}
+			msgs = append(msgs, m.Text)
+		}
+		return nil, fmt.Errorf("esbuild: %s", strings.Join(msgs, "; "))
+	}
+
+	for _, f := range r.OutputFiles {
+		rel, err := filepath.Rel(src, f.Path)
+		if err != nil {
+			return nil, err
+		}
+		rel = filepath.ToSlash(rel)
+		outs = append(outs, Output{Path: rel, Content: f.Contents, NoVersion: path.Ext(rel) == ".map"})
+	}
+	return outs, nil
+}
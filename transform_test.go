@@ -0,0 +1,53 @@
+package filever
+
+import (
+	"fmt"
+	"os"
+)
+
+// ExampleTransform demonstrates a CommandTransformer "minifying" a src file,
+// which is renamed to the dummy form and versioned in one step.
+func ExampleTransform() {
+	for _, d := range []string{transformSrc, transformDist} {
+		err := os.RemoveAll(d)
+		if err != nil {
+			panic(err)
+		}
+		err = os.MkdirAll(d, 0755)
+		if err != nil {
+			panic(err)
+		}
+	}
+	err := os.WriteFile(transformSrc+"/app.js", []byte("console.log( 'app' );"), 0644)
+	if err != nil {
+		panic(err)
+	}
+	err = os.WriteFile(transformDist+"/index.html", []byte(`<script src="app~fv=00000000.min.js"></script>`), 0644)
+	if err != nil {
+		panic(err)
+	}
+
+	c := &Config{Src: transformSrc, Dist: transformDist, Transformers: []Transformer{
+		&CommandTransformer{Glob: "*.js", Command: []string{"tr", "-d", " "}, Out: "{dir}{name}.min{ext}"},
+	}}
+	err = VersionReplace(c)
+	if err != nil {
+		panic(err)
+	}
+	fmt.Println(c.SrcFiles)
+	fmt.Println(c.Info.VersionedFiles)
+	PrintFile(transformDist + "/" + c.Info.VersionedFiles[0])
+	PrintFile(transformDist + "/index.html")
+
+	// Output:
+	// [app~fv=00000000.min.js]
+	// [app~fv=NVoPWitK.min.js]
+	// File test/transform/dist/app~fv=NVoPWitK.min.js:
+	// ////////////////
+	// console.log('app');
+	// ////////////////
+	// File test/transform/dist/index.html:
+	// ////////////////
+	// <script src="app~fv=NVoPWitK.min.js"></script>
+	// ////////////////
+}
@@ -0,0 +1,131 @@
+package filever
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"time"
+)
+
+// UnversionInfo holds the results of Unversion().
+type UnversionInfo struct {
+	// Files are the dummy versioned files written to `out`, relative to `out`.
+	// E.g. `e/app~fv=00000000.min.js`.
+	Files []string
+
+	// Unresolved are references that do not resolve to a versioned file in
+	// `dist`.  Key is the versioned file (relative to `dist`) containing the
+	// reference, value is the list of unresolved references, e.g.
+	// `{"subdir/test_4~fv=GJIrg6k1.js":["./test_3~fv=00000000.js"]}`
+	Unresolved map[string][]string
+}
+
+// Unversion is the reverse of Version and Replace.  It rebuilds a `src` tree in
+// `out` from an existing (e.g. deployed) `dist`.  Versioned files in `dist` are
+// copied to `out` with dummy versions (`app~fv=00000000.min.js`) and their
+// references to versioned files are rewritten back to the dummy version.
+// Non-versioned files in `dist` are ignored.
+//
+// References that do not resolve to a versioned file in `dist` are reported in
+// UnversionInfo.Unresolved and are still re-zeroed.  References are resolved
+// like Replace(), relative to the referencing file.  See resolveRef().
+//
+// If `dist` contains more than one version of the same file, e.g. previous
+// versions retained by Config.Retention, only the current version is
+// unversioned: the version in the manifest (see WriteManifest()), if any,
+// otherwise the most recently modified version, like Prune().
+func Unversion(dist, out string) (info *UnversionInfo, err error) {
+	info = new(UnversionInfo)
+	info.Unresolved = map[string][]string{}
+
+	files, err := ExistingVersionedFilesAnySize(dist)
+	if err != nil {
+		return nil, err
+	}
+	var pv map[string]string
+	m, err := ReadManifest(filepath.Join(dist, MetaDir, ManifestFile))
+	if err == nil {
+		pv = m.PV
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	bares := map[string]string{} // bare path:current versioned file
+	modTimes := map[string]time.Time{}
+	for _, f := range files {
+		p := Populated(filepath.ToSlash(f))
+		if v, ok := pv[p.BarePath]; ok {
+			if p.Version == v {
+				bares[p.BarePath] = f
+			}
+			continue
+		}
+		fi, err := os.Stat(filepath.Join(dist, f))
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := bares[p.BarePath]; !ok || fi.ModTime().After(modTimes[p.BarePath]) {
+			bares[p.BarePath] = f
+			modTimes[p.BarePath] = fi.ModTime()
+		}
+	}
+
+	refReg, err := regexp.Compile(FileVerPathReg)
+	if err != nil {
+		return nil, &ConfigError{Field: "FileVerPathReg", Msg: "is not a valid regex", Err: err}
+	}
+	known := func(bare string) bool { _, ok := bares[bare]; return ok }
+	for _, bare := range sortedKeys(bares) {
+		f := bares[bare]
+		b, err := os.ReadFile(filepath.Join(dist, f))
+		if err != nil {
+			return nil, err
+		}
+
+		for _, ref := range refReg.FindAll(b, -1) {
+			if !known(resolveRef(filepath.ToSlash(f), string(ref), nil, known)) {
+				info.Unresolved[f] = append(info.Unresolved[f], string(ref))
+			}
+		}
+
+		p := Populated(filepath.ToSlash(f))
+		dummy, _ := genFileVer(p.BarePath, DummyVersion(), nil)
+		o := filepath.Join(out, filepath.FromSlash(dummy))
+		err = os.MkdirAll(filepath.Dir(o), 0755)
+		if err != nil {
+			return nil, err
+		}
+		err = os.WriteFile(o, Canonical(b), 0644)
+		if err != nil {
+			return nil, err
+		}
+		info.Files = append(info.Files, dummy)
+	}
+
+	sort.Strings(info.Files)
+	return info, nil
+}
+
+// ExistingVersionedFilesAnySize is like ExistingVersionedFiles, but also
+// returns versioned files with a version of any size, e.g.
+// `app~fv=4mIbJJPqX.min.js`.  Files with an empty version are not returned.
+func ExistingVersionedFilesAnySize(directory string) (fileVers []string, err error) {
+	var walk = func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || Populated(d.Name()).Version == "" {
+			return nil
+		}
+		rel, err := filepath.Rel(directory, path)
+		if err != nil {
+			return err
+		}
+		fileVers = append(fileVers, rel)
+		return nil
+	}
+
+	return fileVers, filepath.WalkDir(directory, walk)
+}
@@ -0,0 +1,101 @@
+package filever
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func ExampleUnversion() {
+	c := &Config{Src: dummySrc, Dist: dummyDist}
+	err := VersionReplace(c)
+	if err != nil {
+		panic(err)
+	}
+
+	info, err := Unversion(dummyDist, unversionOut)
+	if err != nil {
+		panic(err)
+	}
+	PrintPretty(info)
+	PrintFile(unversionOut + "/subdir/test_3~fv=00000000.js")
+
+	// Output:
+	// {
+	// 	"Files": [
+	// 		"subdir/test_3~fv=00000000.js",
+	// 		"subdir/test_4~fv=00000000.js",
+	// 		"test_1~fv=00000000.js",
+	// 		"test_2~fv=00000000.js"
+	// 	],
+	// 	"Unresolved": {}
+	// }
+	// File test/unversion/subdir/test_3~fv=00000000.js:
+	// ////////////////
+	// import * as test1 from '../test_1~fv=00000000.js';
+	// import * as test2 from '../test_2~fv=00000000.js';
+	// import * as test4 from '../subdir/test_4~fv=00000000.js';
+	// ////////////////
+}
+
+// TestUnversion_retention tests that only the current version is unversioned
+// when dist has previous versions, e.g. retained by Config.Retention.
+func TestUnversion_retention(t *testing.T) {
+	for _, d := range []string{unversionRetentionDist, unversionRetentionOut} {
+		err := os.RemoveAll(d)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	err := os.MkdirAll(unversionRetentionDist, 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := &Config{Src: dummySrc, Dist: unversionRetentionDist, Manifest: true, Retention: &Retention{}}
+	err = VersionReplace(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	old := filepath.Join(unversionRetentionDist, "test_1~fv=Ab7ZeAgj.js")
+	err = os.WriteFile(old, []byte("// Old version.\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		name    string
+		modTime time.Time
+	}{
+		{"manifest", time.Now().Add(time.Hour)}, // The manifest wins over modification time.
+		{"modification time", time.Now().Add(-time.Hour)},
+	} {
+		if tc.name == "modification time" {
+			err = os.Remove(filepath.Join(unversionRetentionDist, MetaDir, ManifestFile))
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+		err = os.Chtimes(old, tc.modTime, tc.modTime)
+		if err != nil {
+			t.Fatal(err)
+		}
+		info, err := Unversion(unversionRetentionDist, unversionRetentionOut)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		want := []string{"subdir/test_3~fv=00000000.js", "subdir/test_4~fv=00000000.js", "test_1~fv=00000000.js", "test_2~fv=00000000.js"}
+		if !reflect.DeepEqual(info.Files, want) {
+			t.Errorf("%s: Files = %v, want %v", tc.name, info.Files, want)
+		}
+		b, err := os.ReadFile(filepath.Join(unversionRetentionOut, "test_1~fv=00000000.js"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if strings.Contains(string(b), "Old version") {
+			t.Errorf("%s: unversioned the previous version of test_1.js", tc.name)
+		}
+	}
+}
@@ -0,0 +1,397 @@
+package filever
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"golang.org/x/exp/slices"
+)
+
+// DefaultDebounce is the default duration Watch() waits for a burst of file
+// changes to end before running a cycle.
+var DefaultDebounce = 100 * time.Millisecond
+
+// Cycle is the result of one Watch() cycle.
+//
+//	Changed     - Changed files that triggered the cycle, relative to pwd.  Empty for the initial cycle.
+//	Versioned   - Versioned files written by the cycle, relative to c.Dist.
+//	Updated     - Files updated by Replace(), relative to pwd.
+//	Transformed - Files output by c.Transformers into c.Src, relative to c.Src.
+//	Report      - Report of the cycle.  See Run().
+//	Err         - Error of the cycle, if any.  Watch() continues after errors.
+type Cycle struct {
+	Changed     []string
+	Versioned   []string
+	Updated     []string
+	Transformed []string
+	Report      *Report
+	Err         error
+}
+
+// Watch runs VersionReplace() and then watches c.Src, and c.Dist if
+// c.WatchDist, for changes until ctx is done.  Bursts of changes are debounced
+// by c.Debounce (default DefaultDebounce).  Each cycle only re-versions the
+// changed src files.  If any version changed, Replace() is run on c.Dist;
+// otherwise only the changed files in c.Dist are replaced.  Changed src files
+// matching c.Transformers are transformed and their outputs versioned.  Each
+// cycle that did something, including the initial VersionReplace(), is
+// reported to onCycle, which may be nil.
+//
+// A change in c.Src during a cycle cancels the cycle, which is not reported.
+// Its changed files are re-run, together with the new change, by the next
+// cycle.  Cycles run one at a time.
+//
+// Watch replaces the need for an external watcher (e.g. watchmod) calling
+// FileVer.  Watch returns ctx.Err() when ctx is done, or an error if watching
+// fails.
+func Watch(ctx context.Context, c *Config, onCycle func(*Cycle)) (err error) {
+	if onCycle == nil {
+		onCycle = func(*Cycle) {}
+	}
+	debounce := c.Debounce
+	if debounce <= 0 {
+		debounce = DefaultDebounce
+	}
+
+	srcF, err := newFilter(c, c.Src)
+	if err != nil {
+		return err
+	}
+	distF, err := newFilter(c, c.Dist)
+	if err != nil {
+		return err
+	}
+
+	w, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	defer w.Close()
+	err = watchDirs(w, c.Src)
+	if err != nil {
+		return err
+	}
+	if c.WatchDist {
+		err = watchDirs(w, c.Dist)
+		if err != nil {
+			return err
+		}
+	}
+
+	r, err := Run(ctx, c)
+	cy := &Cycle{Versioned: c.Info.VersionedFiles, Updated: c.Info.UpdatedFilePaths, Report: r, Err: err}
+	onCycle(cy)
+	if err != nil {
+		return err
+	}
+
+	changed := map[string]bool{}
+	written := cycleWritten(c, cy)       // Files written by the last cycle, whose events are ignored.
+	srcFiles := slices.Clone(c.SrcFiles) // c.SrcFiles as of the last cycle, since c is in use by a running cycle.
+	timer := time.NewTimer(debounce)
+	timer.Stop()
+
+	done := make(chan *Cycle, 1)
+	var cancel context.CancelFunc // Cancels the running cycle, if any.
+	due := false                  // Start a cycle when the running cycle ends.
+	force := false                // Replace even if no version changed, after a cancelled cycle.
+	start := func() {
+		var cctx context.Context
+		cctx, cancel = context.WithCancel(ctx)
+		names, f := sortedKeys(changed), force
+		changed, force = map[string]bool{}, false
+		go func() { done <- watchCycle(cctx, c, names, f) }()
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			if cancel != nil { // Don't return while the cycle is using c.
+				<-done
+				cancel()
+			}
+			return ctx.Err()
+		case err := <-w.Errors:
+			return err
+		case e := <-w.Events:
+			if e.Op == fsnotify.Chmod || isWritten(written, e.Name) {
+				continue
+			}
+			if e.Op&fsnotify.Create != 0 {
+				fi, err := os.Stat(e.Name)
+				if err == nil && fi.IsDir() {
+					err = watchDirs(w, e.Name)
+					if err != nil {
+						return err
+					}
+					continue
+				}
+			}
+			if !isWatched(c, srcFiles, srcF, distF, e.Name) {
+				continue
+			}
+			changed[e.Name] = true
+			if cancel != nil && cancels(c, e.Name) {
+				cancel()
+			}
+			timer.Reset(debounce)
+		case <-timer.C:
+			if cancel != nil {
+				due = true
+				continue
+			}
+			start()
+		case cy := <-done:
+			cancel()
+			cancel = nil
+			if errors.Is(cy.Err, context.Canceled) && ctx.Err() == nil {
+				for _, name := range cy.Changed {
+					changed[name] = true
+				}
+				force = true
+			} else {
+				if cy.Err != nil || len(cy.Versioned) > 0 || len(cy.Updated) > 0 || len(cy.Transformed) > 0 {
+					onCycle(cy)
+				}
+				written = cycleWritten(c, cy)
+				for name := range changed { // Events of the cycle's own writes.
+					if isWritten(written, name) {
+						delete(changed, name)
+					}
+				}
+			}
+			srcFiles = slices.Clone(c.SrcFiles)
+			if due && len(changed) > 0 {
+				start()
+			}
+			due = false
+		}
+	}
+}
+
+// cancels reports whether a change to name, relative to pwd, cancels a
+// running cycle.  Only changes in c.Src cancel, except for dummy versioned
+// files when c.Transformers are set, since the cycle itself writes
+// transformer outputs into c.Src.
+func cancels(c *Config, name string) bool {
+	if _, ok := within(c.Src, name); !ok {
+		return false
+	}
+	return len(c.Transformers) == 0 || !strings.Contains(filepath.Base(name), Delim)
+}
+
+// cycleWritten returns the files written to by the cycle, relative to pwd,
+// with their state after the cycle.
+func cycleWritten(c *Config, cy *Cycle) map[string]os.FileInfo {
+	written := map[string]os.FileInfo{}
+	var names []string
+	for _, f := range cy.Versioned {
+		names = append(names, filepath.Join(c.Dist, filepath.FromSlash(f)))
+	}
+	for _, f := range cy.Transformed {
+		names = append(names, filepath.Join(c.Src, f))
+	}
+	for _, name := range append(names, cy.Updated...) {
+		fi, err := os.Stat(name)
+		if err == nil {
+			written[name] = fi
+		}
+	}
+	return written
+}
+
+// isWritten reports whether the event for name is from a cycle writing the
+// file, i.e. the file has not changed since.
+func isWritten(written map[string]os.FileInfo, name string) bool {
+	w, ok := written[name]
+	if !ok {
+		return false
+	}
+	fi, err := os.Stat(name)
+	return err == nil && fi.ModTime().Equal(w.ModTime()) && fi.Size() == w.Size()
+}
+
+// watchCycle versions changed src files and replaces.  changed are relative to
+// pwd.  If force, Replace() is run even if no version changed, e.g. to finish
+// a cancelled cycle.  The cycle stops, with ctx.Err(), when ctx is done.
+func watchCycle(ctx context.Context, c *Config, changed []string, force bool) (cy *Cycle) {
+	cy = &Cycle{Changed: changed, Report: startReport(c)}
+	defer endReport(c)
+	c.Info.UpdatedFilePaths = nil
+	c.Info.CheckedFilePaths = nil
+	c.Info.SkippedFilePaths = nil
+	c.Info.DanglingRefs = nil
+	c.Info.TotalSourceReplaces = 0
+
+	pvChanged := force
+	var distChanged []string
+	var srcChanged []string // Relative to c.Src.
+	start := time.Now()
+	for _, name := range changed {
+		if cy.Err = ctx.Err(); cy.Err != nil {
+			return cy
+		}
+		rel, ok := within(c.Src, name)
+		if !ok {
+			if _, err := os.Stat(name); err == nil { // Non-versioned file in dist.
+				distChanged = append(distChanged, name)
+			}
+			continue
+		}
+		t := matchTransformer(c, filepath.ToSlash(rel))
+		if strings.Contains(filepath.Base(rel), Delim) || t == nil {
+			srcChanged = append(srcChanged, rel)
+			continue
+		}
+		if _, err := os.Stat(name); err != nil { // Removed.  Outputs are left.
+			continue
+		}
+		outs, err := transformFile(c, t, filepath.ToSlash(rel))
+		if err != nil {
+			cy.Err = err
+			return cy
+		}
+		cy.Transformed = append(cy.Transformed, outs...)
+		for _, o := range outs {
+			if !slices.Contains(srcChanged, o) {
+				srcChanged = append(srcChanged, o)
+			}
+		}
+	}
+
+	phase(c, "transform", start)
+
+	start = time.Now()
+	for _, rel := range srcChanged {
+		if cy.Err = ctx.Err(); cy.Err != nil {
+			return cy
+		}
+		name := filepath.Join(c.Src, rel)
+		_, err := os.Stat(name)
+		if os.IsNotExist(err) { // Removed.  Previous versions in dist are left.
+			bare := Populated(filepath.ToSlash(rel)).BarePath
+			delete(c.Info.versions, c.Info.PV[bare])
+			delete(c.Info.PV, bare)
+			if c.Lock == LockUpdate && c.lock != nil {
+				delete(c.lock.Files, bare)
+			}
+			if i := slices.Index(c.SrcFiles, rel); i != -1 {
+				c.SrcFiles = slices.Delete(c.SrcFiles, i, i+1)
+			}
+			if i := slices.IndexFunc(c.Info.VersionedFiles, func(f string) bool { return Populated(f).BarePath == bare }); i != -1 {
+				c.Info.VersionedFiles = slices.Delete(c.Info.VersionedFiles, i, i+1)
+			}
+			pvChanged = true
+			continue
+		}
+		oldVersion := c.Info.PV[Populated(filepath.ToSlash(rel)).BarePath]
+		f, err := versionFile(c, rel)
+		if err != nil {
+			cy.Err = err
+			return cy
+		}
+		if !slices.Contains(c.SrcFiles, rel) {
+			c.SrcFiles = append(c.SrcFiles, rel)
+		}
+		if Populated(f).Version != oldVersion {
+			pvChanged = true
+			cy.Versioned = append(cy.Versioned, f)
+		}
+	}
+
+	phase(c, "version", start)
+
+	if pvChanged {
+		cy.Err = endLock(c) // Rewrites the lock file, or checks for removed files.
+		if cy.Err != nil {
+			return cy
+		}
+		cy.Err = ReplaceContext(ctx, c)
+		if cy.Err == nil {
+			cy.Err = ctx.Err()
+		}
+		if cy.Err == nil {
+			cy.Err = deletePrevious(c)
+		}
+		if cy.Err == nil {
+			_, cy.Err = WriteCompressed(c)
+		}
+		if cy.Err == nil && c.Manifest {
+			cy.Err = WriteManifest(c)
+		}
+		if cy.Err == nil && c.History {
+			_, cy.Err = AppendHistory(c, "")
+		}
+	} else if len(distChanged) > 0 {
+		start = time.Now()
+		defer phase(c, "replace", start)
+		errs := []error{genSrcReg(c)}
+		if errs[0] == nil {
+			for _, name := range distChanged {
+				if ctx.Err() != nil {
+					cy.Err = ctx.Err()
+					return cy
+				}
+				errs = append(errs, replaceFile(c, name))
+			}
+			errs = append(errs, danglingErr(c))
+		}
+		cy.Err = errors.Join(errs...)
+	}
+	sort.Strings(cy.Versioned)
+	cy.Updated = c.Info.UpdatedFilePaths
+	return cy
+}
+
+// isWatched reports whether a change to name, relative to pwd, requires a
+// cycle.  Files in c.Src must be files to be versioned, either enumerated in
+// srcFiles (c.SrcFiles) or dummy versioned files, or files matching
+// c.Transformers.  Files in c.Dist must be non-versioned and not in MetaDir.
+// Files skipped by the filters srcF and distF are not watched.
+func isWatched(c *Config, srcFiles []string, srcF, distF *filter, name string) bool {
+	if rel, ok := within(c.Src, name); ok {
+		rel = filepath.ToSlash(rel)
+		if srcF.excluded(rel) {
+			return false
+		}
+		return slices.Contains(srcFiles, filepath.FromSlash(rel)) || VerRegexC.MatchString(path.Base(rel)) ||
+			(!strings.Contains(path.Base(rel), Delim) && matchTransformer(c, rel) != nil)
+	}
+	if rel, ok := within(c.Dist, name); ok && c.WatchDist {
+		rel = filepath.ToSlash(rel)
+		return !strings.Contains(path.Base(rel), Delim) && rel != MetaDir && !strings.HasPrefix(rel, MetaDir+"/") && !distF.excluded(rel)
+	}
+	return false
+}
+
+// within returns name relative to dir, if name is within dir.
+func within(dir, name string) (rel string, ok bool) {
+	rel, err := filepath.Rel(dir, name)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(os.PathSeparator)) {
+		return "", false
+	}
+	return rel, true
+}
+
+// watchDirs adds dir and its subdirectories, except MetaDir, to w.
+func watchDirs(w *fsnotify.Watcher, dir string) error {
+	return filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		if d.Name() == MetaDir {
+			return filepath.SkipDir
+		}
+		return w.Add(path)
+	})
+}
@@ -0,0 +1,89 @@
+package filever
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestWatch(t *testing.T) {
+	for _, d := range []string{watchFSSrc, watchFSDist} {
+		err := os.RemoveAll(d)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = os.MkdirAll(d, 0755)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	write := func(name, content string) {
+		err := os.WriteFile(name, []byte(content), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	write(watchFSSrc+"/app~fv=00000000.js", "console.log('1');")
+	write(watchFSDist+"/index.html", `<script src="app~fv=00000000.js"></script>`)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	c := &Config{Src: watchFSSrc, Dist: watchFSDist, WatchDist: true, Debounce: 50 * time.Millisecond}
+	cycles := make(chan *Cycle)
+	done := make(chan error)
+	go func() { done <- Watch(ctx, c, func(cy *Cycle) { cycles <- cy }) }()
+
+	next := func() *Cycle {
+		select {
+		case cy := <-cycles:
+			if cy.Err != nil {
+				t.Fatal(cy.Err)
+			}
+			return cy
+		case <-ctx.Done():
+			t.Fatal("timed out waiting for cycle")
+		}
+		return nil
+	}
+	expectIndex := func(want string) {
+		b, err := os.ReadFile(watchFSDist + "/index.html")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(b) != want {
+			t.Fatalf("index.html: got %s, want %s", b, want)
+		}
+	}
+
+	// Initial cycle.
+	cy := next()
+	if len(cy.Versioned) != 1 || cy.Versioned[0] != "app~fv=iHcj4NUQ.js" {
+		t.Fatalf("initial cycle versioned: %v", cy.Versioned)
+	}
+	expectIndex(`<script src="app~fv=iHcj4NUQ.js"></script>`)
+
+	// Change in src.
+	write(watchFSSrc+"/app~fv=00000000.js", "console.log('2');")
+	cy = next()
+	if len(cy.Versioned) != 1 || cy.Versioned[0] != "app~fv=KtSwHPJy.js" {
+		t.Fatalf("src cycle versioned: %v", cy.Versioned)
+	}
+	expectIndex(`<script src="app~fv=KtSwHPJy.js"></script>`)
+	if _, err := os.Stat(watchFSDist + "/app~fv=iHcj4NUQ.js"); !os.IsNotExist(err) {
+		t.Fatalf("previous version not removed: %v", err)
+	}
+
+	// Change of a non-versioned file in dist.
+	write(watchFSDist+"/index.html", `<script src="./app~fv=00000000.js"></script>`)
+	cy = next()
+	if len(cy.Versioned) != 0 || len(cy.Updated) != 1 {
+		t.Fatalf("dist cycle: %+v", cy)
+	}
+	expectIndex(`<script src="./app~fv=KtSwHPJy.js"></script>`)
+
+	cancel()
+	if err := <-done; err != context.Canceled {
+		t.Fatal(err)
+	}
+}